@@ -1,6 +1,8 @@
 package acceptance
 
 import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/provider"
 	"os"
@@ -10,6 +12,18 @@ import (
 var AzureADB2CProvider *schema.Provider
 var once sync.Once
 
+// ProtoV5ProviderFactories serve AzureADB2CProvider through the mux server, as Terraform runs it.
+var ProtoV5ProviderFactories = map[string]func() (tfprotov5.ProviderServer, error){
+	"azureadb2cief": func() (tfprotov5.ProviderServer, error) {
+		EnsureProvidersAreInitialised()
+		server, err := provider.NewMuxServer(context.Background(), "dev", AzureADB2CProvider)
+		if err != nil {
+			return nil, err
+		}
+		return server(), nil
+	},
+}
+
 func init() {
 	if os.Getenv("TF_ACC") == "" {
 		return
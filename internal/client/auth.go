@@ -0,0 +1,252 @@
+package client
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/graph"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// authMethod is a way of authenticating to Microsoft Graph.  Methods are tried in the
+// order of authMethods and the first one whose skipReason is empty is used.
+type authMethod struct {
+	name string
+	// skipReason returns why the method can't be used with the given configuration, or an empty string if it can.
+	skipReason func(config MsGraphClientConfig) string
+	build      func(config MsGraphClientConfig, env graph.Environment) (azcore.TokenCredential, error)
+}
+
+var authMethods = []authMethod{
+	{
+		name: "Client Certificate",
+		skipReason: func(config MsGraphClientConfig) string {
+			if isBlank(config.ClientCertificate) && isBlank(config.ClientCertificatePath) {
+				return "neither client_certificate nor client_certificate_path is set"
+			}
+			return requireServicePrincipal(config)
+		},
+		build: newClientCertificateCredential,
+	},
+	{
+		name: "Client Secret",
+		skipReason: func(config MsGraphClientConfig) string {
+			if isBlank(config.ClientSecret) {
+				return "client_secret is not set"
+			}
+			return requireServicePrincipal(config)
+		},
+		build: func(config MsGraphClientConfig, env graph.Environment) (azcore.TokenCredential, error) {
+			return azidentity.NewClientSecretCredential(config.TenantID, config.ClientID, config.ClientSecret, &azidentity.ClientSecretCredentialOptions{
+				AuthorityHost: azidentity.AuthorityHost(env.AuthorityHost),
+			})
+		},
+	},
+	{
+		name: "OIDC",
+		skipReason: func(config MsGraphClientConfig) string {
+			if !config.EnableOidc {
+				return "use_oidc is false"
+			}
+			if isBlank(config.OidcToken) && isBlank(config.OidcTokenFilePath) && (isBlank(config.OidcRequestUrl) || isBlank(config.OidcRequestToken)) {
+				return "none of oidc_token, oidc_token_file_path, or oidc_request_url and oidc_request_token are set"
+			}
+			return requireServicePrincipal(config)
+		},
+		build: newOidcCredential,
+	},
+	{
+		name: "Managed Identity",
+		skipReason: func(config MsGraphClientConfig) string {
+			if !config.EnableMsi {
+				return "use_msi is false"
+			}
+			return ""
+		},
+		build: newManagedIdentityCredential,
+	},
+	{
+		name: "Azure CLI",
+		skipReason: func(config MsGraphClientConfig) string {
+			if !config.EnableAzureCliToken {
+				return "use_cli is false"
+			}
+			if isBlank(config.TenantID) {
+				return "tenant_id is not set"
+			}
+			return ""
+		},
+		build: func(config MsGraphClientConfig, _ graph.Environment) (azcore.TokenCredential, error) {
+			return azidentity.NewAzureCLICredential(&azidentity.AzureCLICredentialOptions{TenantID: config.TenantID})
+		},
+	},
+}
+
+// AuthError is returned when no credential could be built for Microsoft Graph.  Method is the
+// selected auth method, or empty when none was usable, and Skipped lists why earlier methods were skipped.
+type AuthError struct {
+	Method  string
+	Err     error
+	Skipped []string
+}
+
+func (e *AuthError) Summary() string {
+	if e.Method == "" {
+		return "no Authorizer could be configured, please check your configuration"
+	}
+	return fmt.Sprintf("could not configure %s authentication", e.Method)
+}
+
+func (e *AuthError) Detail() string {
+	var detail string
+	if e.Err != nil {
+		detail = e.Err.Error()
+	}
+	if len(e.Skipped) > 0 {
+		if detail != "" {
+			detail += "\n\n"
+		}
+		detail += "Skipped authentication methods:\n  - " + strings.Join(e.Skipped, "\n  - ")
+	}
+	return detail
+}
+
+func (e *AuthError) Error() string {
+	if detail := e.Detail(); detail != "" {
+		return fmt.Sprintf("%s: %s", e.Summary(), detail)
+	}
+	return e.Summary()
+}
+
+func (e *AuthError) Unwrap() error {
+	return e.Err
+}
+
+// newCredential builds the credential for the first usable auth method.
+func newCredential(config MsGraphClientConfig, env graph.Environment) (azcore.TokenCredential, error) {
+	var skipped []string
+	for _, method := range authMethods {
+		if reason := method.skipReason(config); reason != "" {
+			skipped = append(skipped, fmt.Sprintf("%s: %s", method.name, reason))
+			continue
+		}
+
+		log.Printf("[DEBUG] Using %s authentication for Microsoft Graph", method.name)
+		for _, s := range skipped {
+			log.Printf("[DEBUG] Skipped authentication method %s", s)
+		}
+
+		cred, err := method.build(config, env)
+		if err != nil {
+			return nil, &AuthError{Method: method.name, Err: err, Skipped: skipped}
+		}
+		return cred, nil
+	}
+
+	return nil, &AuthError{Skipped: skipped}
+}
+
+func newClientCertificateCredential(config MsGraphClientConfig, env graph.Environment) (azcore.TokenCredential, error) {
+	if !isBlank(config.ClientCertificate) && !isBlank(config.ClientCertificatePath) {
+		return nil, fmt.Errorf("only one of client_certificate and client_certificate_path can be set")
+	}
+
+	var certData []byte
+	var err error
+	if !isBlank(config.ClientCertificate) {
+		certData, err = base64.StdEncoding.DecodeString(strings.TrimSpace(config.ClientCertificate))
+		if err != nil {
+			return nil, fmt.Errorf("client_certificate is not valid base64: %s", err)
+		}
+	} else {
+		certData, err = os.ReadFile(config.ClientCertificatePath)
+		if err != nil {
+			return nil, fmt.Errorf("could not read client_certificate_path %q: %s", config.ClientCertificatePath, err)
+		}
+	}
+
+	var password []byte
+	if config.ClientCertificatePassword != "" {
+		password = []byte(config.ClientCertificatePassword)
+	}
+	certs, key, err := azidentity.ParseCertificates(certData, password)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse client certificate: %s", err)
+	}
+
+	return azidentity.NewClientCertificateCredential(config.TenantID, config.ClientID, certs, key, &azidentity.ClientCertificateCredentialOptions{
+		AuthorityHost: azidentity.AuthorityHost(env.AuthorityHost),
+	})
+}
+
+func newOidcCredential(config MsGraphClientConfig, env graph.Environment) (azcore.TokenCredential, error) {
+	var source assertionSource
+	if !isBlank(config.OidcToken) {
+		source = staticAssertion(strings.TrimSpace(config.OidcToken))
+	} else if !isBlank(config.OidcTokenFilePath) {
+		source = fileAssertion(config.OidcTokenFilePath)
+	} else {
+		source = requestAssertion(&http.Client{}, config.OidcRequestUrl, config.OidcRequestToken)
+	}
+
+	return newClientAssertionCredential(config.TenantID, config.ClientID, env.AuthorityHost, source), nil
+}
+
+func newManagedIdentityCredential(config MsGraphClientConfig, _ graph.Environment) (azcore.TokenCredential, error) {
+	options := azidentity.ManagedIdentityCredentialOptions{}
+	if !isBlank(config.ClientID) {
+		options.ID = azidentity.ClientID(config.ClientID)
+	}
+	if !isBlank(config.MsiEndpoint) {
+		endpoint, err := url.Parse(config.MsiEndpoint)
+		if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+			return nil, fmt.Errorf("msi_endpoint %q is not a valid URL", config.MsiEndpoint)
+		}
+		options.Transport = &msiEndpointTransport{endpoint: endpoint, client: &http.Client{}}
+	}
+
+	return azidentity.NewManagedIdentityCredential(&options)
+}
+
+// imdsHost is the address of the Azure Instance Metadata Service which serves managed identity tokens.
+const imdsHost = "169.254.169.254"
+
+// msiEndpointTransport sends requests meant for the Instance Metadata Service to a custom endpoint instead.
+type msiEndpointTransport struct {
+	endpoint *url.URL
+	client   *http.Client
+}
+
+func (t *msiEndpointTransport) Do(req *http.Request) (*http.Response, error) {
+	if req.URL.Hostname() == imdsHost {
+		req = req.Clone(req.Context())
+		req.URL.Scheme = t.endpoint.Scheme
+		req.URL.Host = t.endpoint.Host
+		req.URL.Path = t.endpoint.Path
+		req.Host = t.endpoint.Host
+	}
+	return t.client.Do(req)
+}
+
+func requireServicePrincipal(config MsGraphClientConfig) string {
+	var missing []string
+	if isBlank(config.TenantID) {
+		missing = append(missing, "tenant_id")
+	}
+	if isBlank(config.ClientID) {
+		missing = append(missing, "client_id")
+	}
+	if len(missing) > 0 {
+		return fmt.Sprintf("%s not set", strings.Join(missing, " and "))
+	}
+	return ""
+}
+
+func isBlank(s string) bool {
+	return strings.TrimSpace(s) == ""
+}
@@ -0,0 +1,199 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/graph"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testTenantID = "00000000-0000-0000-0000-000000000000"
+const testClientID = "11111111-1111-1111-1111-111111111111"
+
+func testCertificatePEM(t *testing.T) []byte {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "terraform-provider-azureadb2cief"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return append(certPEM, keyPEM...)
+}
+
+func TestNewCredentialClientCertificate(t *testing.T) {
+	certData := testCertificatePEM(t)
+
+	cred, err := newCredential(MsGraphClientConfig{
+		TenantID:            testTenantID,
+		ClientID:            testClientID,
+		ClientSecret:        "secret",
+		ClientCertificate:   base64.StdEncoding.EncodeToString(certData),
+		EnableAzureCliToken: true,
+	}, graph.Environments[graph.DefaultEnvironment])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cred.(*azidentity.ClientCertificateCredential); !ok {
+		t.Fatalf("expected a ClientCertificateCredential, got %T", cred)
+	}
+
+	path := filepath.Join(t.TempDir(), "cert.pem")
+	if err := os.WriteFile(path, certData, 0600); err != nil {
+		t.Fatal(err)
+	}
+	cred, err = newCredential(MsGraphClientConfig{
+		TenantID:              testTenantID,
+		ClientID:              testClientID,
+		ClientCertificatePath: path,
+	}, graph.Environments[graph.DefaultEnvironment])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cred.(*azidentity.ClientCertificateCredential); !ok {
+		t.Fatalf("expected a ClientCertificateCredential, got %T", cred)
+	}
+}
+
+func TestNewCredentialInvalidCertificate(t *testing.T) {
+	_, err := newCredential(MsGraphClientConfig{
+		TenantID:          testTenantID,
+		ClientID:          testClientID,
+		ClientCertificate: "not base64!",
+	}, graph.Environments[graph.DefaultEnvironment])
+
+	var authErr *AuthError
+	if !errors.As(err, &authErr) {
+		t.Fatalf("expected an AuthError, got %v", err)
+	}
+	if authErr.Method != "Client Certificate" {
+		t.Fatalf("expected Client Certificate to be selected, got %q", authErr.Method)
+	}
+	if !strings.Contains(authErr.Detail(), "not valid base64") {
+		t.Fatalf("unexpected detail: %s", authErr.Detail())
+	}
+}
+
+func TestNewCredentialSkipReasons(t *testing.T) {
+	_, err := newCredential(MsGraphClientConfig{
+		ClientSecret:        "secret",
+		EnableAzureCliToken: false,
+	}, graph.Environments[graph.DefaultEnvironment])
+
+	var authErr *AuthError
+	if !errors.As(err, &authErr) {
+		t.Fatalf("expected an AuthError, got %v", err)
+	}
+	if authErr.Method != "" {
+		t.Fatalf("expected no method to be selected, got %q", authErr.Method)
+	}
+
+	expected := []string{
+		"Client Certificate: neither client_certificate nor client_certificate_path is set",
+		"Client Secret: tenant_id and client_id not set",
+		"OIDC: use_oidc is false",
+		"Managed Identity: use_msi is false",
+		"Azure CLI: use_cli is false",
+	}
+	for _, e := range expected {
+		if !strings.Contains(authErr.Detail(), e) {
+			t.Errorf("expected detail to contain %q, got:\n%s", e, authErr.Detail())
+		}
+	}
+}
+
+func TestNewCredentialManagedIdentity(t *testing.T) {
+	t.Setenv("MSI_ENDPOINT", "")
+	t.Setenv("IDENTITY_ENDPOINT", "")
+
+	var tokenRequests int
+	imds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metadata/identity/oauth2/token" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		// IMDS rejects requests without the Metadata header, which is how azidentity probes for it.
+		if r.Header.Get("Metadata") != "true" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		tokenRequests++
+		if got := r.URL.Query().Get("client_id"); got != testClientID {
+			t.Errorf("expected client_id %s, got %q", testClientID, got)
+		}
+		if got := r.URL.Query().Get("resource"); got != "https://graph.microsoft.com" {
+			t.Errorf("unexpected resource %q", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "msi-token",
+			"expires_in":   "3599",
+			"token_type":   "Bearer",
+		})
+	}))
+	defer imds.Close()
+
+	cred, err := newCredential(MsGraphClientConfig{
+		TenantID:            testTenantID,
+		ClientID:            testClientID,
+		EnableMsi:           true,
+		MsiEndpoint:         imds.URL + "/metadata/identity/oauth2/token",
+		EnableAzureCliToken: true,
+	}, graph.Environments[graph.DefaultEnvironment])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cred.(*azidentity.ManagedIdentityCredential); !ok {
+		t.Fatalf("expected a ManagedIdentityCredential, got %T", cred)
+	}
+
+	token, err := cred.GetToken(context.Background(), policy.TokenRequestOptions{
+		Scopes: []string{"https://graph.microsoft.com/.default"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Token != "msi-token" {
+		t.Fatalf("expected msi-token, got %s", token.Token)
+	}
+	if tokenRequests != 1 {
+		t.Fatalf("expected 1 token request, got %d", tokenRequests)
+	}
+}
+
+func TestNewCredentialInvalidMsiEndpoint(t *testing.T) {
+	_, err := newCredential(MsGraphClientConfig{
+		EnableMsi:   true,
+		MsiEndpoint: "not a url",
+	}, graph.Environments[graph.DefaultEnvironment])
+
+	var authErr *AuthError
+	if !errors.As(err, &authErr) || authErr.Method != "Managed Identity" {
+		t.Fatalf("expected a Managed Identity AuthError, got %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package client
+
+import (
+	"context"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/util"
+	"log"
+	"strings"
+	"sync"
+)
+
+// basePolicies caches the base policy of the policies of the tenant for a run, so that finding the
+// policies inheriting from every deleted policy does not read each policy again.
+type basePolicies struct {
+	mu     sync.Mutex
+	byName map[string]string
+}
+
+// BasePolicy returns the PolicyId of the base policy of a policy, or an empty string when it has none
+// or its XML can't be parsed.  The policy is only read the first time.
+func (c *Client) BasePolicy(ctx context.Context, name string) (string, error) {
+	key := strings.ToLower(name)
+	c.basePolicies.mu.Lock()
+	basePolicy, ok := c.basePolicies.byName[key]
+	c.basePolicies.mu.Unlock()
+	if ok {
+		return basePolicy, nil
+	}
+
+	policy, err := c.TrustFrameworkPolicyClient.Get(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	parsed, err := util.ParseTrustFrameworkPolicy(policy.Policy)
+	if err != nil {
+		log.Printf("[WARN] Could not parse Trust Framework Policy %q to find its base policy: %s", name, err)
+	} else if parsed.BasePolicy != nil {
+		basePolicy = parsed.BasePolicy.PolicyId
+	}
+
+	c.basePolicies.mu.Lock()
+	defer c.basePolicies.mu.Unlock()
+	if c.basePolicies.byName == nil {
+		c.basePolicies.byName = map[string]string{}
+	}
+	c.basePolicies.byName[key] = basePolicy
+	return basePolicy, nil
+}
+
+// ForgetBasePolicy drops the cached base policy of a policy that was uploaded.
+func (c *Client) ForgetBasePolicy(name string) {
+	c.basePolicies.mu.Lock()
+	defer c.basePolicies.mu.Unlock()
+	delete(c.basePolicies.byName, strings.ToLower(name))
+}
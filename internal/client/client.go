@@ -1,20 +1,205 @@
 package client
 
+import (
+	"context"
+	"fmt"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/graph"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/pkg/b2c"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+type MsGraphClientConfig struct {
+	TenantID                  string
+	ClientID                  string
+	ClientSecret              string
+	ClientCertificate         string
+	ClientCertificatePath     string
+	ClientCertificatePassword string
+	EnableAzureCliToken       bool
+	EnableOidc                bool
+	OidcToken                 string
+	OidcTokenFilePath         string
+	OidcRequestUrl            string
+	OidcRequestToken          string
+	EnableMsi                 bool
+	MsiEndpoint               string
+	Environment               string
+	GraphEndpoint             string
+	MaxRetries                int
+	MaxRequestsPerSecond      float64
+	LogBodyMaxBytes           int
+	ProviderVersion           string
+	TerraformVersion          string
+	PartnerId                 string
+	BatchRequests             bool
+	// Credential, when set, is used instead of the authentication methods above.
+	Credential azcore.TokenCredential
+	// UserAgent, when set, replaces the provider and Terraform versions in the User-Agent.
+	UserAgent string
+	// ReadOnly refuses every request other than GET, so that the tenant cannot be changed.
+	ReadOnly bool
+	// PolicyNamePrefix, when set, replaces the B2C_1A_ prefix of policy names in the tenant, and of
+	// key set names when PrefixKeySets is true.
+	PolicyNamePrefix string
+	PrefixKeySets    bool
+	// AdoptExisting makes resources adopt the policies and key sets that already exist when they
+	// are created, unless a resource sets its own adopt_existing.
+	AdoptExisting bool
+	// PolicyPropagationTimeout is how long to wait after a policy is uploaded until it is returned by
+	// reads, which are repeated at intervals doubling from PolicyPropagationMinInterval up to
+	// PolicyPropagationMaxInterval.  Zero does not wait.
+	PolicyPropagationTimeout     time.Duration
+	PolicyPropagationMinInterval time.Duration
+	PolicyPropagationMaxInterval time.Duration
+	// PolicyHistoryDirectory, when set, keeps the XML of policies before they are overwritten, up to
+	// PolicyHistoryMaxVersions versions per policy.
+	PolicyHistoryDirectory   string
+	PolicyHistoryMaxVersions int
+}
+
+const (
+	DefaultPolicyPropagationTimeout     = 5 * time.Minute
+	DefaultPolicyPropagationMinInterval = time.Second
+	DefaultPolicyPropagationMaxInterval = 15 * time.Second
+)
+
+// Client is the provider's meta.  Resources use the policy and key set clients through the b2c
+// interfaces, so they can be tested with fakes.
 type Client struct {
-	TrustFrameworkPolicyClient *TrustFrameworkPolicyClient
-	TrustFrameworkKeySetClient *TrustFrameworkKeySetClient
+	TrustFrameworkPolicyClient b2c.PolicyClient
+	TrustFrameworkKeySetClient b2c.KeySetClient
+	OrganizationClient         *graph.OrganizationClient
 	Config                     *MsGraphClientConfig
+	graph                      *graph.Client
+
+	domainsMutex      sync.Mutex
+	domainsRead       bool
+	domains           []string
+	domainsErr        error
+	domainsErrPending bool
+
+	basePolicies basePolicies
 }
 
+// New returns the client of the provider, which authenticates with the configured method and logs
+// Microsoft Graph requests with tflog.
 func New(config MsGraphClientConfig) (*Client, error) {
-	bc, err := newBaseClient(config)
+	env, err := graph.LookupEnvironment(config.Environment)
+	if err != nil {
+		return nil, err
+	}
+
+	cred := config.Credential
+	if cred == nil {
+		cred, err = newCredential(config, env)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	g, err := graph.New(graph.Config{
+		TenantID:             config.TenantID,
+		Credential:           cred,
+		Environment:          env.Name,
+		GraphEndpoint:        config.GraphEndpoint,
+		MaxRetries:           config.MaxRetries,
+		MaxRequestsPerSecond: config.MaxRequestsPerSecond,
+		BatchRequests:        config.BatchRequests,
+		UserAgent:            userAgent(config),
+		ReadOnly:             config.ReadOnly,
+		PolicyNamePrefix:     config.PolicyNamePrefix,
+		PrefixKeySets:        config.PrefixKeySets,
+		WrapTransport: func(next http.RoundTripper) http.RoundTripper {
+			return &loggingTransport{
+				maxBodyBytes: config.LogBodyMaxBytes,
+				secrets:      []string{config.ClientSecret, config.ClientCertificatePassword},
+				next:         next,
+			}
+		},
+	})
 	if err != nil {
 		return nil, err
 	}
 
 	return &Client{
-		TrustFrameworkPolicyClient: newPolicyClient(bc),
-		TrustFrameworkKeySetClient: newKeySetClient(bc),
+		TrustFrameworkPolicyClient: g.Policies,
+		TrustFrameworkKeySetClient: g.KeySets,
+		OrganizationClient:         g.Organization,
 		Config:                     &config,
+		graph:                      g,
 	}, nil
 }
+
+// userAgent identifies the provider and Terraform versions, and the partner the usage is attributed to.
+func userAgent(config MsGraphClientConfig) string {
+	var parts []string
+	if !isBlank(config.UserAgent) {
+		parts = append(parts, strings.TrimSpace(config.UserAgent))
+	} else {
+		providerVersion := config.ProviderVersion
+		if isBlank(providerVersion) {
+			providerVersion = "dev"
+		}
+		parts = append(parts, "terraform-provider-azureadb2cief/"+strings.TrimSpace(providerVersion))
+		if !isBlank(config.TerraformVersion) {
+			parts = append(parts, "terraform/"+strings.TrimSpace(config.TerraformVersion))
+		}
+	}
+	if !isBlank(config.PartnerId) {
+		parts = append(parts, "pid-"+strings.TrimSpace(config.PartnerId))
+	}
+	return strings.Join(parts, " ")
+}
+
+// ReadOnly reports whether the client refuses requests that could change the tenant.
+func (c *Client) ReadOnly() bool {
+	return c.Config != nil && c.Config.ReadOnly
+}
+
+// TenantDomains returns the domain names of the tenant: tenant_id when it is a domain name, and
+// otherwise the verified domains of the organization, which are read once.  It returns no domains
+// when they cannot be known, with the error of the read when it failed, which is not read again.
+func (c *Client) TenantDomains(ctx context.Context) ([]string, error) {
+	if c.Config == nil {
+		return nil, nil
+	}
+	tenantId := strings.TrimSpace(c.Config.TenantID)
+	if strings.Contains(tenantId, ".") {
+		return []string{tenantId}, nil
+	}
+
+	c.domainsMutex.Lock()
+	defer c.domainsMutex.Unlock()
+	if c.domainsRead || c.OrganizationClient == nil {
+		return c.domains, c.domainsErr
+	}
+	c.domainsRead = true
+	org, err := c.OrganizationClient.Get(ctx)
+	if err != nil {
+		log.Printf("[WARN] Could not read the verified domains of tenant %s: %s", tenantId, err)
+		c.domainsErr = fmt.Errorf("could not read the verified domains of tenant %s: %w", tenantId, err)
+		c.domainsErrPending = true
+		return nil, c.domainsErr
+	}
+	for _, domain := range org.VerifiedDomains {
+		c.domains = append(c.domains, domain.Name)
+	}
+	return c.domains, nil
+}
+
+// UnreportedTenantDomainsError returns the error of reading the domains of the tenant the first time
+// it is called after the read failed, so that it is reported once per run.
+func (c *Client) UnreportedTenantDomainsError() error {
+	c.domainsMutex.Lock()
+	defer c.domainsMutex.Unlock()
+	if !c.domainsErrPending {
+		return nil
+	}
+	c.domainsErrPending = false
+	return c.domainsErr
+}
@@ -0,0 +1,215 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// assertionRefreshWindow is how long before its exp claim a cached client assertion is fetched again.
+const assertionRefreshWindow = time.Minute
+
+// oidcAudience is the audience federated identity credentials expect by default.
+const oidcAudience = "api://AzureADTokenExchange"
+
+// clientAssertionCredential authenticates a service principal with a federated OIDC token, such as
+// the ID tokens issued by GitHub Actions or Azure DevOps, instead of a secret or certificate.
+type clientAssertionCredential struct {
+	tenantID      string
+	clientID      string
+	authorityHost string
+	assertion     *assertionCache
+	client        *http.Client
+}
+
+func newClientAssertionCredential(tenantID string, clientID string, authorityHost string, source assertionSource) *clientAssertionCredential {
+	return &clientAssertionCredential{
+		tenantID:      tenantID,
+		clientID:      clientID,
+		authorityHost: authorityHost,
+		assertion:     newAssertionCache(source),
+		client:        &http.Client{},
+	}
+}
+
+func (c *clientAssertionCredential) GetToken(ctx context.Context, options policy.TokenRequestOptions) (*azcore.AccessToken, error) {
+	assertion, err := c.assertion.get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not obtain OIDC token: %s", err)
+	}
+
+	tenantID := c.tenantID
+	if options.TenantID != "" {
+		tenantID = options.TenantID
+	}
+	form := url.Values{
+		"client_id":             {c.clientID},
+		"scope":                 {strings.Join(options.Scopes, " ")},
+		"grant_type":            {"client_credentials"},
+		"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+		"client_assertion":      {assertion},
+	}
+	tokenUrl := strings.TrimSuffix(c.authorityHost, "/") + "/" + url.PathEscape(tenantID) + "/oauth2/v2.0/token"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenUrl, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	response, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("unexpected status %d from token endpoint with response: %s", response.StatusCode, body)
+	}
+	if response.StatusCode != http.StatusOK || result.AccessToken == "" {
+		return nil, fmt.Errorf("client assertion was rejected with status %d: %s %s", response.StatusCode, result.Error, result.ErrorDescription)
+	}
+
+	return &azcore.AccessToken{
+		Token:     result.AccessToken,
+		ExpiresOn: time.Now().Add(time.Duration(result.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// assertionSource returns a fresh OIDC token to use as a client assertion.
+type assertionSource func(ctx context.Context) (string, error)
+
+func staticAssertion(token string) assertionSource {
+	return func(_ context.Context) (string, error) {
+		return token, nil
+	}
+}
+
+// fileAssertion reads the token from a file on every call, as workload identity rotates it in place.
+func fileAssertion(path string) assertionSource {
+	return func(_ context.Context) (string, error) {
+		token, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("could not read oidc_token_file_path %q: %s", path, err)
+		}
+		return strings.TrimSpace(string(token)), nil
+	}
+}
+
+// requestAssertion requests a new token from a CI system's token endpoint, such as the one
+// GitHub Actions exposes as ACTIONS_ID_TOKEN_REQUEST_URL.
+func requestAssertion(client *http.Client, requestUrl string, requestToken string) assertionSource {
+	return func(ctx context.Context) (string, error) {
+		u, err := url.Parse(requestUrl)
+		if err != nil {
+			return "", fmt.Errorf("oidc_request_url is not a valid URL: %s", err)
+		}
+		query := u.Query()
+		query.Set("audience", oidcAudience)
+		u.RawQuery = query.Encode()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), http.NoBody)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Authorization", "Bearer "+requestToken)
+
+		response, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(io.LimitReader(response.Body, 4096))
+			return "", fmt.Errorf("oidc_request_url returned %s: %s", response.Status, strings.TrimSpace(string(body)))
+		}
+
+		var result struct {
+			Value string `json:"value"`
+		}
+		if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+			return "", fmt.Errorf("could not parse the oidc_request_url response: %s", err)
+		}
+		if result.Value == "" {
+			return "", fmt.Errorf("oidc_request_url returned an empty token")
+		}
+		return result.Value, nil
+	}
+}
+
+// assertionCache keeps an assertion until shortly before the exp claim of the token expires.
+// Tokens without a readable exp claim are fetched again for every access token request.
+type assertionCache struct {
+	source assertionSource
+	now    func() time.Time
+
+	mu        sync.Mutex
+	assertion string
+	expiresOn time.Time
+}
+
+func newAssertionCache(source assertionSource) *assertionCache {
+	return &assertionCache{source: source, now: time.Now}
+}
+
+func (c *assertionCache) get(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.assertion != "" && c.now().Before(c.expiresOn.Add(-assertionRefreshWindow)) {
+		return c.assertion, nil
+	}
+
+	assertion, err := c.source(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	c.assertion = assertion
+	c.expiresOn = jwtExpiry(assertion)
+	return assertion, nil
+}
+
+// jwtExpiry returns the exp claim of a JWT without validating it, or the zero time if it has none.
+func jwtExpiry(token string) time.Time {
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := decodeJwtClaims(token, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}
+	}
+	return time.Unix(claims.Exp, 0)
+}
+
+// decodeJwtClaims unmarshals the payload of a JWT into claims without validating its signature.
+func decodeJwtClaims(token string, claims interface{}) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("token is not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return fmt.Errorf("could not decode the token payload: %s", err)
+	}
+	return json.Unmarshal(payload, claims)
+}
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/graph"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func testJwt(exp time.Time, subject string) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d,"sub":%q}`, exp.Unix(), subject)))
+	return header + "." + payload + ".signature"
+}
+
+// newTestOidcServers starts a stand-in CI token endpoint issuing short-lived ID tokens and a
+// stand-in Azure AD token endpoint that records the assertions it receives.
+func newTestOidcServers(t *testing.T, idTokenLifetime time.Duration) (*httptest.Server, *httptest.Server, *[]string) {
+	var issued int
+	requestServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer request-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("audience") != oidcAudience {
+			t.Errorf("unexpected audience %q", r.URL.Query().Get("audience"))
+		}
+		issued++
+		json.NewEncoder(w).Encode(map[string]string{
+			"value": testJwt(time.Now().Add(idTokenLifetime), fmt.Sprintf("run-%d", issued)),
+		})
+	}))
+
+	var assertions []string
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/"+testTenantID+"/oauth2/v2.0/token" {
+			t.Errorf("unexpected token path %s", r.URL.Path)
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		if r.PostForm.Get("client_id") != testClientID {
+			t.Errorf("unexpected client_id %q", r.PostForm.Get("client_id"))
+		}
+		assertions = append(assertions, r.PostForm.Get("client_assertion"))
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": fmt.Sprintf("access-%d", len(assertions)),
+			"expires_in":   3600,
+		})
+	}))
+
+	t.Cleanup(requestServer.Close)
+	t.Cleanup(tokenServer.Close)
+	return requestServer, tokenServer, &assertions
+}
+
+func TestClientAssertionCredentialRequestUrl(t *testing.T) {
+	requestServer, tokenServer, assertions := newTestOidcServers(t, 10*time.Minute)
+
+	cred := newClientAssertionCredential(testTenantID, testClientID, tokenServer.URL, requestAssertion(requestServer.Client(), requestServer.URL+"/token?api-version=2.0", "request-token"))
+	options := policy.TokenRequestOptions{Scopes: []string{"https://graph.microsoft.com/.default"}}
+
+	token, err := cred.GetToken(context.Background(), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Token != "access-1" {
+		t.Fatalf("expected access-1, got %s", token.Token)
+	}
+
+	if _, err := cred.GetToken(context.Background(), options); err != nil {
+		t.Fatal(err)
+	}
+	if (*assertions)[0] != (*assertions)[1] {
+		t.Fatal("expected the unexpired ID token to be reused")
+	}
+
+	// Simulate a long apply that outlives the ID token.
+	cred.assertion.now = func() time.Time { return time.Now().Add(10 * time.Minute) }
+	if _, err := cred.GetToken(context.Background(), options); err != nil {
+		t.Fatal(err)
+	}
+	if (*assertions)[1] == (*assertions)[2] {
+		t.Fatal("expected an expired ID token to be requested again")
+	}
+}
+
+func TestClientAssertionCredentialRejected(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error":             "invalid_client",
+			"error_description": "AADSTS70021: No matching federated identity record found",
+		})
+	}))
+	defer tokenServer.Close()
+
+	cred := newClientAssertionCredential(testTenantID, testClientID, tokenServer.URL, staticAssertion("token"))
+	_, err := cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{"https://graph.microsoft.com/.default"}})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if expected := "client assertion was rejected with status 400: invalid_client AADSTS70021: No matching federated identity record found"; err.Error() != expected {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func TestRequestAssertionFailed(t *testing.T) {
+	requestServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Bad credentials", http.StatusUnauthorized)
+	}))
+	defer requestServer.Close()
+
+	_, err := requestAssertion(requestServer.Client(), requestServer.URL, "request-token")(context.Background())
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if expected := "oidc_request_url returned 401 Unauthorized: Bad credentials"; err.Error() != expected {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func TestNewCredentialOidc(t *testing.T) {
+	cred, err := newCredential(MsGraphClientConfig{
+		TenantID:            testTenantID,
+		ClientID:            testClientID,
+		EnableOidc:          true,
+		OidcRequestUrl:      "https://example.com/token",
+		OidcRequestToken:    "request-token",
+		EnableAzureCliToken: true,
+	}, graph.Environments[graph.DefaultEnvironment])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cred.(*clientAssertionCredential); !ok {
+		t.Fatalf("expected a clientAssertionCredential, got %T", cred)
+	}
+}
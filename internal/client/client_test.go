@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type staticCredential string
+
+func (s staticCredential) GetToken(_ context.Context, _ policy.TokenRequestOptions) (*azcore.AccessToken, error) {
+	return &azcore.AccessToken{Token: string(s), ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+// newTestClient returns a client that sends all Graph requests to handler.  Requests are sent with the
+// access token token-1 unless config has a credential.
+func newTestClient(t *testing.T, config MsGraphClientConfig, handler http.Handler) *Client {
+	graph := httptest.NewServer(handler)
+	t.Cleanup(graph.Close)
+
+	config.TenantID = testTenantID
+	config.GraphEndpoint = graph.URL
+	if config.Credential == nil {
+		config.Credential = staticCredential("token-1")
+	}
+	c, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestUserAgent(t *testing.T) {
+	cases := map[string]struct {
+		config   MsGraphClientConfig
+		expected string
+	}{
+		"versions": {
+			config:   MsGraphClientConfig{ProviderVersion: "0.3.0", TerraformVersion: "1.1.0"},
+			expected: "terraform-provider-azureadb2cief/0.3.0 terraform/1.1.0",
+		},
+		"partner": {
+			config:   MsGraphClientConfig{ProviderVersion: "0.3.0", TerraformVersion: "1.1.0", PartnerId: "a3b8c6d2-1f4e-4b7a-9c0d-2e5f8a1b3c4d"},
+			expected: "terraform-provider-azureadb2cief/0.3.0 terraform/1.1.0 pid-a3b8c6d2-1f4e-4b7a-9c0d-2e5f8a1b3c4d",
+		},
+		"unknown versions": {
+			config:   MsGraphClientConfig{},
+			expected: "terraform-provider-azureadb2cief/dev",
+		},
+		"custom": {
+			config:   MsGraphClientConfig{ProviderVersion: "0.3.0", UserAgent: "drift-report/1.0", PartnerId: "a3b8c6d2-1f4e-4b7a-9c0d-2e5f8a1b3c4d"},
+			expected: "drift-report/1.0 pid-a3b8c6d2-1f4e-4b7a-9c0d-2e5f8a1b3c4d",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if actual := userAgent(c.config); actual != c.expected {
+				t.Fatalf("expected %q, got %q", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestGrantedPermissions(t *testing.T) {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"roles":["Policy.ReadWrite.TrustFramework"],"scp":"User.Read Policy.Read.All"}`))
+	c := newTestClient(t, MsGraphClientConfig{Credential: staticCredential("eyJhbGciOiJub25lIn0." + payload + ".sig")}, http.NotFoundHandler())
+
+	granted, err := c.GrantedPermissions(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"Policy.ReadWrite.TrustFramework", "User.Read", "Policy.Read.All"}
+	if !reflect.DeepEqual(granted, expected) {
+		t.Fatalf("expected %v, got %v", expected, granted)
+	}
+
+	c = newTestClient(t, MsGraphClientConfig{Credential: staticCredential("opaque")}, http.NotFoundHandler())
+	if _, err := c.GrantedPermissions(context.Background()); err == nil {
+		t.Fatal("expected an error for a token that is not a JWT")
+	}
+}
+func TestTenantDomainsFailureIsCached(t *testing.T) {
+	var requests int
+	c := newTestClient(t, MsGraphClientConfig{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"error":{"code":"Authorization_RequestDenied","message":"Insufficient privileges"}}`))
+	}))
+
+	for i := 0; i < 3; i++ {
+		if domains, err := c.TenantDomains(context.Background()); err == nil || domains != nil {
+			t.Fatalf("expected the failure to be returned, got %v (%v)", domains, err)
+		}
+	}
+	if requests != 1 {
+		t.Fatalf("expected the organization to be read once, got %d requests", requests)
+	}
+	if err := c.UnreportedTenantDomainsError(); err == nil {
+		t.Fatal("expected the failure to be reported")
+	}
+	if err := c.UnreportedTenantDomainsError(); err != nil {
+		t.Fatalf("expected the failure to be reported once, got %v", err)
+	}
+}
@@ -0,0 +1,213 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/graph"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// graphLogSubsystem is the tflog subsystem of Microsoft Graph request traces.  Its level can be
+	// set separately with TF_LOG_PROVIDER_AZUREADB2CIEF_GRAPH.
+	graphLogSubsystem = "graph"
+
+	// DefaultLogBodyMaxBytes is how much of a request or response body is logged by default.
+	DefaultLogBodyMaxBytes = 4096
+
+	redacted = "[REDACTED]"
+)
+
+// sensitiveBodyFields are JSON properties and form values which are never logged: key set secrets
+// and private JWK members, PKCS#12 passwords and keys, and OAuth credentials and tokens.
+var sensitiveBodyFields = map[string]bool{
+	"k":                true,
+	"d":                true,
+	"p":                true,
+	"q":                true,
+	"dp":               true,
+	"dq":               true,
+	"qi":               true,
+	"key":              true,
+	"password":         true,
+	"client_secret":    true,
+	"client_assertion": true,
+	"access_token":     true,
+	"refresh_token":    true,
+	"id_token":         true,
+}
+
+// sensitiveHeaders are headers whose values are never logged.
+var sensitiveHeaders = map[string]bool{
+	"Authorization": true,
+	"Cookie":        true,
+	"Set-Cookie":    true,
+}
+
+// withGraphLogging adds the Microsoft Graph logging subsystem to the context.  Every secret the
+// client knows about is masked by the logger as well, in case it shows up somewhere unexpected.
+func withGraphLogging(ctx context.Context, secrets ...string) context.Context {
+	ctx = tflog.NewSubsystem(ctx, graphLogSubsystem, tflog.WithLevelFromEnv("TF_LOG_PROVIDER_AZUREADB2CIEF", graphLogSubsystem))
+
+	var masked []string
+	for _, secret := range secrets {
+		if secret != "" {
+			masked = append(masked, secret)
+		}
+	}
+	if len(masked) > 0 {
+		ctx = tflog.SubsystemMaskLogStrings(ctx, graphLogSubsystem, masked...)
+	}
+	return ctx
+}
+
+// loggingTransport traces every attempt of a Microsoft Graph request, including retries, with
+// secrets redacted and bodies truncated to maxBodyBytes.  Bodies are not logged when maxBodyBytes is 0.
+// The access token of the request and the secrets are masked.
+type loggingTransport struct {
+	maxBodyBytes int
+	secrets      []string
+	next         http.RoundTripper
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	ctx := withGraphLogging(req.Context(), append([]string{token}, t.secrets...)...)
+	if !tflog.SubsystemIsDebug(ctx, graphLogSubsystem) {
+		return t.next.RoundTrip(req)
+	}
+
+	fields := map[string]interface{}{
+		"http_method":          req.Method,
+		"http_url":             req.URL.String(),
+		"retry_count":          graph.RetryAttempts(ctx),
+		"http_request_headers": redactHeaders(req.Header),
+	}
+	if t.maxBodyBytes > 0 && req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		fields["http_request_body"] = t.formatBody(req.Header.Get("Content-Type"), body)
+	}
+	tflog.SubsystemDebug(ctx, graphLogSubsystem, "Sending Microsoft Graph request", fields)
+
+	start := time.Now()
+	response, err := t.next.RoundTrip(req)
+	latency := time.Since(start)
+
+	fields = map[string]interface{}{
+		"http_method": req.Method,
+		"http_url":    req.URL.String(),
+		"retry_count": graph.RetryAttempts(ctx),
+		"latency_ms":  latency.Milliseconds(),
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+		tflog.SubsystemDebug(ctx, graphLogSubsystem, "Microsoft Graph request failed", fields)
+		return response, err
+	}
+
+	fields["http_status"] = response.StatusCode
+	fields["http_response_headers"] = redactHeaders(response.Header)
+	if t.maxBodyBytes > 0 && response.Body != nil {
+		body, readErr := io.ReadAll(response.Body)
+		response.Body.Close()
+		response.Body = io.NopCloser(bytes.NewReader(body))
+		if readErr != nil {
+			fields["http_response_body_error"] = readErr.Error()
+		} else {
+			fields["http_response_body"] = t.formatBody(response.Header.Get("Content-Type"), body)
+		}
+	}
+	tflog.SubsystemDebug(ctx, graphLogSubsystem, "Received Microsoft Graph response", fields)
+
+	return response, nil
+}
+
+// formatBody redacts and truncates a body for logging.
+func (t *loggingTransport) formatBody(contentType string, body []byte) string {
+	return truncateBody(redactBody(contentType, body), t.maxBodyBytes)
+}
+
+func redactHeaders(header http.Header) map[string]string {
+	redactedHeader := make(map[string]string, len(header))
+	for name, values := range header {
+		if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+			redactedHeader[name] = redacted
+			continue
+		}
+		redactedHeader[name] = strings.Join(values, ", ")
+	}
+	return redactedHeader
+}
+
+// redactBody removes sensitive values from JSON and form bodies.  A JSON body that cannot be parsed
+// is not logged at all, as there is no way to tell whether it holds a secret.
+func redactBody(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case strings.HasSuffix(mediaType, "json"):
+		var value interface{}
+		if err := json.Unmarshal(body, &value); err != nil {
+			return fmt.Sprintf("[%d byte JSON body redacted: %s]", len(body), err)
+		}
+		redactedBody, err := json.Marshal(redactValue(value))
+		if err != nil {
+			return fmt.Sprintf("[%d byte JSON body redacted: %s]", len(body), err)
+		}
+		return string(redactedBody)
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return fmt.Sprintf("[%d byte form body redacted: %s]", len(body), err)
+		}
+		for name := range values {
+			if sensitiveBodyFields[strings.ToLower(name)] {
+				values[name] = []string{redacted}
+			}
+		}
+		return values.Encode()
+	default:
+		return string(body)
+	}
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for name, child := range v {
+			if sensitiveBodyFields[strings.ToLower(name)] {
+				v[name] = redacted
+			} else {
+				v[name] = redactValue(child)
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = redactValue(child)
+		}
+	}
+	return value
+}
+
+func truncateBody(body string, maxBytes int) string {
+	if len(body) <= maxBytes {
+		return body
+	}
+	return strings.ToValidUTF8(body[:maxBytes], "") + fmt.Sprintf("... [truncated %d bytes]", len(body)-maxBytes)
+}
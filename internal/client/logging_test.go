@@ -0,0 +1,207 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/pkg/b2c"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// testKeySecret must not contain the client secret of newLoggedTestClient, which the logger masks.
+const testKeySecret = "Xk9pQ2vL7tRz4mWb"
+
+// newLoggedTestClient returns a client whose traces are written to the returned buffer.
+func newLoggedTestClient(t *testing.T, maxBodyBytes int, handler http.Handler) (*Client, *bytes.Buffer, context.Context) {
+	c := newTestClient(t, MsGraphClientConfig{ClientSecret: "secret", MaxRetries: 1, LogBodyMaxBytes: maxBodyBytes}, handler)
+
+	var sink bytes.Buffer
+	return c, &sink, tflogtest.RootLogger(context.Background(), &sink)
+}
+
+func logEntries(t *testing.T, sink *bytes.Buffer) []map[string]interface{} {
+	entries, err := tflogtest.MultilineJSONDecode(sink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func TestLoggingRedactsUploadedSecret(t *testing.T) {
+	c, sink, ctx := newLoggedTestClient(t, DefaultLogBodyMaxBytes, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), testKeySecret) {
+			t.Errorf("expected the secret to be sent to Microsoft Graph, got %s", body)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("request-id", "5b3a9c2d-0000-4c3e-9d6f-0123456789ab")
+		w.Write([]byte(`{"kid":"B2C_1A_TestKeySet","use":"sig","kty":"oct","k":"` + testKeySecret + `"}`))
+	}))
+
+	kty, use, k := "oct", "sig", testKeySecret
+	if err := c.TrustFrameworkKeySetClient.UploadSecret(ctx, "B2C_1A_TestKeySet", b2c.TrustFrameworkKey{Kty: &kty, Use: &use, K: &k}); err != nil {
+		t.Fatal(err)
+	}
+
+	log := sink.String()
+	if strings.Contains(log, testKeySecret) {
+		t.Fatalf("the uploaded secret reached the log:\n%s", log)
+	}
+	if strings.Contains(log, "token-1") {
+		t.Fatalf("the access token reached the log:\n%s", log)
+	}
+
+	entries := logEntries(t, sink)
+	if len(entries) != 2 {
+		t.Fatalf("expected a request and a response entry, got %d:\n%s", len(entries), log)
+	}
+	request, response := entries[0], entries[1]
+	if request["http_method"] != http.MethodPost || !strings.HasSuffix(request["http_url"].(string), "/beta/trustFramework/keySets/B2C_1A_TestKeySet/uploadSecret") {
+		t.Fatalf("unexpected request entry %v", request)
+	}
+	if headers := request["http_request_headers"].(map[string]interface{}); headers["Authorization"] != redacted {
+		t.Fatalf("expected the Authorization header to be redacted, got %v", headers["Authorization"])
+	}
+	var requestBody map[string]interface{}
+	if err := json.Unmarshal([]byte(request["http_request_body"].(string)), &requestBody); err != nil {
+		t.Fatal(err)
+	}
+	if requestBody["k"] != redacted || requestBody["kty"] != "oct" {
+		t.Fatalf("unexpected request body %v", requestBody)
+	}
+	if response["http_status"] != float64(http.StatusOK) || response["retry_count"] != float64(0) {
+		t.Fatalf("unexpected response entry %v", response)
+	}
+	if _, ok := response["latency_ms"]; !ok {
+		t.Fatalf("expected the latency to be logged, got %v", response)
+	}
+	if headers := response["http_response_headers"].(map[string]interface{}); headers["Request-Id"] != "5b3a9c2d-0000-4c3e-9d6f-0123456789ab" {
+		t.Fatalf("expected the request-id to be logged, got %v", headers)
+	}
+}
+
+func TestLoggingMasksEchoedToken(t *testing.T) {
+	c, sink, ctx := newLoggedTestClient(t, DefaultLogBodyMaxBytes, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A misbehaving server which echoes the bearer token in a body that is not redacted.
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte("invalid token " + strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")))
+	}))
+
+	if _, err := c.TrustFrameworkPolicyClient.Get(ctx, "B2C_1A_Test"); err == nil {
+		t.Fatal("expected an error")
+	}
+	if log := sink.String(); strings.Contains(log, "token-1") {
+		t.Fatalf("the access token reached the log:\n%s", log)
+	}
+}
+
+func TestLoggingRecordsRetries(t *testing.T) {
+	attempts := 0
+	c, sink, ctx := newLoggedTestClient(t, DefaultLogBodyMaxBytes, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("<TrustFrameworkPolicy />"))
+	}))
+	if _, err := c.TrustFrameworkPolicyClient.Get(ctx, "B2C_1A_Test"); err != nil {
+		t.Fatal(err)
+	}
+
+	var retries []interface{}
+	for _, entry := range logEntries(t, sink) {
+		if _, ok := entry["http_status"]; ok {
+			retries = append(retries, entry["retry_count"])
+		}
+	}
+	if len(retries) != 2 || retries[0] != float64(0) || retries[1] != float64(1) {
+		t.Fatalf("expected a response entry for each attempt, got retry counts %v", retries)
+	}
+}
+
+func TestLoggingTruncatesBodies(t *testing.T) {
+	policy := "<TrustFrameworkPolicy>" + strings.Repeat("<ClaimsProviders />", 100) + "</TrustFrameworkPolicy>"
+	c, sink, ctx := newLoggedTestClient(t, 32, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(policy))
+	}))
+
+	result, err := c.TrustFrameworkPolicyClient.Get(ctx, "B2C_1A_Test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Policy != policy {
+		t.Fatal("expected logging not to change the response body")
+	}
+
+	entries := logEntries(t, sink)
+	body := entries[len(entries)-1]["http_response_body"].(string)
+	if body != policy[:32]+fmt.Sprintf("... [truncated %d bytes]", len(policy)-32) {
+		t.Fatalf("unexpected truncated body %q", body)
+	}
+}
+
+func TestLoggingWithoutBodies(t *testing.T) {
+	c, sink, ctx := newLoggedTestClient(t, 0, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"B2C_1A_TestKeySet","keys":[]}`))
+	}))
+
+	if _, err := c.TrustFrameworkKeySetClient.GetKeySet(ctx, "B2C_1A_TestKeySet"); err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range logEntries(t, sink) {
+		if _, ok := entry["http_response_body"]; ok {
+			t.Fatalf("expected no bodies to be logged, got %v", entry)
+		}
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+	cases := map[string]struct {
+		contentType string
+		body        string
+		expected    string
+	}{
+		"pkcs12 upload": {
+			contentType: "application/json",
+			body:        `{"key":"MIIKcQIBAzCCCjcGCSqGSIb3DQEHAaCCCigEggok","password":"pfx-password"}`,
+			expected:    `{"key":"[REDACTED]","password":"[REDACTED]"}`,
+		},
+		"nested private key": {
+			contentType: "application/json; charset=utf-8",
+			body:        `{"keys":[{"kty":"RSA","n":"modulus","d":"private-exponent"}]}`,
+			expected:    `{"keys":[{"d":"[REDACTED]","kty":"RSA","n":"modulus"}]}`,
+		},
+		"invalid json": {
+			contentType: "application/json",
+			body:        `{"k":"truncated-secret`,
+			expected:    "[22 byte JSON body redacted: unexpected end of JSON input]",
+		},
+		"token request": {
+			contentType: "application/x-www-form-urlencoded",
+			body:        "client_assertion=eyJhbGciOi&client_id=abc&grant_type=client_credentials",
+			expected:    "client_assertion=%5BREDACTED%5D&client_id=abc&grant_type=client_credentials",
+		},
+		"policy": {
+			contentType: "application/xml",
+			body:        "<TrustFrameworkPolicy />",
+			expected:    "<TrustFrameworkPolicy />",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if actual := redactBody(c.contentType, []byte(c.body)); actual != c.expected {
+				t.Fatalf("expected %s, got %s", c.expected, actual)
+			}
+		})
+	}
+}
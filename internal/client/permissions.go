@@ -0,0 +1,33 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Microsoft Graph permissions needed to manage trustFramework policies and key sets.
+const (
+	PolicyReadWritePermission = "Policy.ReadWrite.TrustFramework"
+	KeySetReadWritePermission = "TrustFrameworkKeySet.ReadWrite.All"
+)
+
+// GrantedPermissions returns the application roles and delegated scopes in the access token used
+// for Microsoft Graph requests.  The token is decoded without validation, so the result is only
+// useful for diagnostics.
+func (c *Client) GrantedPermissions(ctx context.Context) ([]string, error) {
+	token, err := c.graph.AccessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims struct {
+		Roles []string `json:"roles"`
+		Scp   string   `json:"scp"`
+	}
+	if err := decodeJwtClaims(token.Token, &claims); err != nil {
+		return nil, fmt.Errorf("could not read the permissions of the access token: %s", err)
+	}
+
+	return append(claims.Roles, strings.Fields(claims.Scp)...), nil
+}
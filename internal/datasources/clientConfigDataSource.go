@@ -3,28 +3,68 @@ package datasources
 import (
 	"context"
 	"fmt"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/client"
 )
 
-func ClientConfigDataSource() *schema.Resource {
-	return &schema.Resource{
-		ReadContext: clientConfigDataSourceRead,
-		Schema: map[string]*schema.Schema{
-			"tenant_id": {
-				Description: "The tenant ID of the authenticated principal",
-				Type:        schema.TypeString,
-				Computed:    true,
+var _ datasource.DataSourceWithConfigure = &clientConfigDataSource{}
+
+func NewClientConfigDataSource() datasource.DataSource {
+	return &clientConfigDataSource{}
+}
+
+type clientConfigDataSource struct {
+	client *client.Client
+}
+
+type clientConfigModel struct {
+	Id       types.String `tfsdk:"id"`
+	TenantId types.String `tfsdk:"tenant_id"`
+}
+
+func (d *clientConfigDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_client_config"
+}
+
+func (d *clientConfigDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The tenant ID of the authenticated principal, the same as `tenant_id`",
+			},
+			"tenant_id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The tenant ID of the authenticated principal",
 			},
 		},
 	}
 }
 
-func clientConfigDataSourceRead(_ context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	graphClient := meta.(*client.Client)
-	d.SetId(fmt.Sprintf("%s", graphClient.Config.TenantID))
-	d.Set("tenant_id", graphClient.Config.TenantID)
+func (d *clientConfigDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	c, ok := req.ProviderData.(*client.Client)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected provider data", fmt.Sprintf("Expected *client.Client, got %T", req.ProviderData))
+		return
+	}
+	d.client = c
+}
+
+func (d *clientConfigDataSource) Read(ctx context.Context, _ datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.client == nil {
+		resp.Diagnostics.AddError("Provider not configured", "The azureadb2cief_client_config data source was read before the provider was configured.")
+		return
+	}
 
-	return nil
+	state := clientConfigModel{
+		Id:       types.StringValue(d.client.Config.TenantID),
+		TenantId: types.StringValue(d.client.Config.TenantID),
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
 }
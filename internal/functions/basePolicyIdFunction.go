@@ -0,0 +1,42 @@
+package functions
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ function.Function = &basePolicyIdFunction{}
+
+func NewBasePolicyIdFunction() function.Function {
+	return &basePolicyIdFunction{}
+}
+
+type basePolicyIdFunction struct{}
+
+func (f *basePolicyIdFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "base_policy_id"
+}
+
+func (f *basePolicyIdFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:             "Returns the PolicyId of the policy a policy inherits from",
+		MarkdownDescription: "Returns the `PolicyId` of the `BasePolicy` element of a policy, or `null` for a policy which does not inherit from another policy.",
+		Parameters:          []function.Parameter{policyXmlParameter},
+		Return:              function.StringReturn{},
+	}
+}
+
+func (f *basePolicyIdFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	policy, funcErr := parsePolicyArgument(ctx, req)
+	if funcErr != nil {
+		resp.Error = funcErr
+		return
+	}
+
+	basePolicyId := types.StringNull()
+	if policy.BasePolicy != nil && policy.BasePolicy.PolicyId != "" {
+		basePolicyId = types.StringValue(policy.BasePolicy.PolicyId)
+	}
+	resp.Error = resp.Result.Set(ctx, basePolicyId)
+}
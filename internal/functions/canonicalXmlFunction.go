@@ -0,0 +1,44 @@
+package functions
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/util"
+)
+
+var _ function.Function = &canonicalXmlFunction{}
+
+func NewCanonicalXmlFunction() function.Function {
+	return &canonicalXmlFunction{}
+}
+
+type canonicalXmlFunction struct{}
+
+func (f *canonicalXmlFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "canonical_xml"
+}
+
+func (f *canonicalXmlFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Returns the canonical form of a policy",
+		MarkdownDescription: "Returns the canonical form of a policy's XML, without the XML declaration, comments and whitespace between elements.  " +
+			"Two policies have the same canonical form exactly when the `azureadb2cief_trust_framework_policy` resource treats them as equal.",
+		Parameters: []function.Parameter{policyXmlParameter},
+		Return:     function.StringReturn{},
+	}
+}
+
+func (f *canonicalXmlFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	policyXml, funcErr := policyXmlArgument(ctx, req)
+	if funcErr != nil {
+		resp.Error = funcErr
+		return
+	}
+
+	canonical, err := util.CanonicalXml(policyXml)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, "could not parse the XML: "+err.Error())
+		return
+	}
+	resp.Error = resp.Result.Set(ctx, canonical)
+}
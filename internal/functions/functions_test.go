@@ -0,0 +1,107 @@
+package functions
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/util"
+	"strings"
+	"testing"
+)
+
+const testPolicy = `<?xml version="1.0" encoding="utf-8" ?>
+<TrustFrameworkPolicy xmlns="http://schemas.microsoft.com/online/cpim/schemas/2013/06" TenantId="contoso.onmicrosoft.com" PolicyId="B2C_1A_signup_signin" PublicPolicyUri="http://contoso.onmicrosoft.com/B2C_1A_signup_signin">
+  <!-- Inherits the claims providers of the extensions policy -->
+  <BasePolicy>
+    <TenantId>contoso.onmicrosoft.com</TenantId>
+    <PolicyId> B2C_1A_TrustFrameworkExtensions </PolicyId>
+  </BasePolicy>
+  <ClaimsProviders>
+    <ClaimsProvider>
+      <TechnicalProfiles>
+        <TechnicalProfile Id="JwtIssuer">
+          <CryptographicKeys>
+            <Key Id="issuer_secret" StorageReferenceId="B2C_1A_TokenSigningKeyContainer" />
+            <Key Id="issuer_refresh_token_key" StorageReferenceId="B2C_1A_TokenEncryptionKeyContainer" />
+          </CryptographicKeys>
+        </TechnicalProfile>
+        <TechnicalProfile Id="SM-Jwt">
+          <CryptographicKeys>
+            <Key Id="issuer_secret" StorageReferenceId="B2C_1A_TokenSigningKeyContainer" />
+          </CryptographicKeys>
+        </TechnicalProfile>
+      </TechnicalProfiles>
+    </ClaimsProvider>
+  </ClaimsProviders>
+</TrustFrameworkPolicy>`
+
+// run calls a function with a single argument and returns its result.
+func run(t *testing.T, f function.Function, argument string, result attr.Value) (attr.Value, *function.FuncError) {
+	resp := &function.RunResponse{Result: function.NewResultData(result)}
+	f.Run(context.Background(), function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{types.StringValue(argument)}),
+	}, resp)
+	return resp.Result.Value(), resp.Error
+}
+
+func TestCanonicalXmlFunction(t *testing.T) {
+	result, err := run(t, NewCanonicalXmlFunction(), testPolicy, types.StringUnknown())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := util.CanonicalXml(testPolicy)
+	if !result.Equal(types.StringValue(expected)) {
+		t.Fatalf("expected %s, got %s", expected, result)
+	}
+	if strings.Contains(expected, "Inherits") {
+		t.Fatal("expected the comment to be removed")
+	}
+
+	if _, err := run(t, NewCanonicalXmlFunction(), "<TrustFrameworkPolicy>", types.StringUnknown()); err == nil || err.FunctionArgument == nil || *err.FunctionArgument != 0 {
+		t.Fatalf("expected an error for the xml argument, got %v", err)
+	}
+}
+
+func TestPolicyIdFunction(t *testing.T) {
+	result, err := run(t, NewPolicyIdFunction(), testPolicy, types.StringUnknown())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Equal(types.StringValue("B2C_1A_signup_signin")) {
+		t.Fatalf("unexpected policy id %s", result)
+	}
+
+	if _, err := run(t, NewPolicyIdFunction(), `<TrustFrameworkPolicy />`, types.StringUnknown()); err == nil || !strings.Contains(err.Text, "PolicyId") {
+		t.Fatalf("expected an error for a policy without a PolicyId, got %v", err)
+	}
+}
+
+func TestBasePolicyIdFunction(t *testing.T) {
+	result, err := run(t, NewBasePolicyIdFunction(), testPolicy, types.StringUnknown())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Equal(types.StringValue("B2C_1A_TrustFrameworkExtensions")) {
+		t.Fatalf("unexpected base policy id %s", result)
+	}
+
+	result, err = run(t, NewBasePolicyIdFunction(), `<TrustFrameworkPolicy PolicyId="B2C_1A_TrustFrameworkBase" />`, types.StringUnknown())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Equal(types.StringNull()) {
+		t.Fatalf("expected null for a policy without a base policy, got %s", result)
+	}
+}
+
+func TestReferencedKeySetsFunction(t *testing.T) {
+	result, err := run(t, NewReferencedKeySetsFunction(), testPolicy, types.ListUnknown(types.StringType))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := types.ListValueFrom(context.Background(), types.StringType, []string{"B2C_1A_TokenEncryptionKeyContainer", "B2C_1A_TokenSigningKeyContainer"})
+	if !result.Equal(expected) {
+		t.Fatalf("expected %s, got %s", expected, result)
+	}
+}
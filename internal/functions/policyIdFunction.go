@@ -0,0 +1,36 @@
+package functions
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+var _ function.Function = &policyIdFunction{}
+
+func NewPolicyIdFunction() function.Function {
+	return &policyIdFunction{}
+}
+
+type policyIdFunction struct{}
+
+func (f *policyIdFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "policy_id"
+}
+
+func (f *policyIdFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:             "Returns the PolicyId of a policy",
+		MarkdownDescription: "Returns the `PolicyId` attribute of the `TrustFrameworkPolicy` element of a policy, the name the policy is uploaded with.",
+		Parameters:          []function.Parameter{policyXmlParameter},
+		Return:              function.StringReturn{},
+	}
+}
+
+func (f *policyIdFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	policy, funcErr := parsePolicyArgument(ctx, req)
+	if funcErr != nil {
+		resp.Error = funcErr
+		return
+	}
+	resp.Error = resp.Result.Set(ctx, policy.PolicyId)
+}
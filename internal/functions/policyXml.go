@@ -0,0 +1,36 @@
+package functions
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/util"
+)
+
+// policyXmlParameter is the policy XML argument of the functions.
+var policyXmlParameter = function.StringParameter{
+	Name:                "xml",
+	MarkdownDescription: "The XML of a Trust Framework policy",
+}
+
+// policyXmlArgument reads the policy XML argument.
+func policyXmlArgument(ctx context.Context, req function.RunRequest) (string, *function.FuncError) {
+	var policyXml string
+	if err := req.Arguments.Get(ctx, &policyXml); err != nil {
+		return "", err
+	}
+	return policyXml, nil
+}
+
+// parsePolicyArgument reads the policy XML argument and its root element.
+func parsePolicyArgument(ctx context.Context, req function.RunRequest) (*util.TrustFrameworkPolicy, *function.FuncError) {
+	policyXml, funcErr := policyXmlArgument(ctx, req)
+	if funcErr != nil {
+		return nil, funcErr
+	}
+
+	policy, err := util.ParseTrustFrameworkPolicy(policyXml)
+	if err != nil {
+		return nil, function.NewArgumentFuncError(0, err.Error())
+	}
+	return policy, nil
+}
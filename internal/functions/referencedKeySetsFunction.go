@@ -0,0 +1,44 @@
+package functions
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/util"
+)
+
+var _ function.Function = &referencedKeySetsFunction{}
+
+func NewReferencedKeySetsFunction() function.Function {
+	return &referencedKeySetsFunction{}
+}
+
+type referencedKeySetsFunction struct{}
+
+func (f *referencedKeySetsFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "referenced_key_sets"
+}
+
+func (f *referencedKeySetsFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:             "Returns the key sets a policy refers to",
+		MarkdownDescription: "Returns the names of the key sets a policy refers to with `StorageReferenceId` attributes, sorted and without duplicates.",
+		Parameters:          []function.Parameter{policyXmlParameter},
+		Return:              function.ListReturn{ElementType: types.StringType},
+	}
+}
+
+func (f *referencedKeySetsFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	policyXml, funcErr := policyXmlArgument(ctx, req)
+	if funcErr != nil {
+		resp.Error = funcErr
+		return
+	}
+
+	keySets, err := util.ReferencedKeySets(policyXml)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+	resp.Error = resp.Result.Set(ctx, keySets)
+}
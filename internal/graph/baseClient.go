@@ -0,0 +1,169 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/hashicorp/go-uuid"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/pkg/b2c"
+	"go.opentelemetry.io/otel/attribute"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+type baseClient struct {
+	cred            azcore.TokenCredential
+	config          Config
+	baseUrl         string
+	scopes          []string
+	client          *retryablehttp.Client
+	userAgent       string
+	clientRequestId string
+	batcher         *batcher
+}
+
+func newBaseClient(config Config) (*baseClient, error) {
+	env, err := LookupEnvironment(config.Environment)
+	if err != nil {
+		return nil, err
+	}
+	if config.Credential == nil {
+		return nil, fmt.Errorf("no credential to authenticate to Microsoft Graph with")
+	}
+
+	graphEndpoint := env.GraphEndpoint
+	if !isBlank(config.GraphEndpoint) {
+		graphEndpoint = strings.TrimSuffix(strings.TrimSpace(config.GraphEndpoint), "/")
+	}
+
+	client := retryablehttp.NewClient()
+	client.RetryMax = config.MaxRetries
+	client.CheckRetry = graphRetryPolicy(config.MaxRetries)
+	client.Backoff = graphBackoff
+	if config.WrapTransport != nil {
+		client.HTTPClient.Transport = config.WrapTransport(client.HTTPClient.Transport)
+	}
+	client.HTTPClient.Transport = &tracingTransport{next: client.HTTPClient.Transport}
+	if limiter := tenantRateLimiter(config.TenantID, config.MaxRequestsPerSecond); limiter != nil {
+		client.HTTPClient.Transport = &rateLimitedTransport{limiter: limiter, next: client.HTTPClient.Transport}
+	}
+
+	// Every request of a run shares a client-request-id, so they can be found together in the tenant's logs.
+	clientRequestId, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[INFO] Microsoft Graph requests are sent with client-request-id %s", clientRequestId)
+
+	gc := &baseClient{
+		cred:    newTokenCache(config.Credential),
+		config:  config,
+		baseUrl: graphEndpoint + "/beta",
+		scopes: []string{
+			env.graphScope(),
+		},
+		client:          client,
+		userAgent:       strings.TrimSpace(config.UserAgent),
+		clientRequestId: clientRequestId,
+	}
+	if config.BatchRequests {
+		gc.batcher = newBatcher(gc)
+	}
+	return gc, nil
+}
+
+func (gc *baseClient) doRequest(ctx context.Context, path string, method string, body io.Reader, contentType *string) (*http.Response, error) {
+	response, _, err := gc.doRequestCountingRetries(ctx, path, method, body, contentType)
+	return response, err
+}
+
+// doGet sends a GET request, batched with concurrent reads when batching is enabled.
+func (gc *baseClient) doGet(ctx context.Context, path string) (*http.Response, error) {
+	if gc.batcher == nil {
+		return gc.doRequest(ctx, path, http.MethodGet, http.NoBody, nil)
+	}
+
+	ctx, span := gc.startRequestSpan(ctx, http.MethodGet, path)
+	span.SetAttributes(attribute.Bool("azureadb2cief.graph.batched", true))
+	response, err := gc.batcher.get(ctx, path)
+	err = interrupted(ctx, http.MethodGet, path, err)
+	endRequestSpan(span, response, 0, err)
+	return response, err
+}
+
+// doRequestCountingRetries is doRequest that also returns how many times the request was retried.
+func (gc *baseClient) doRequestCountingRetries(ctx context.Context, path string, method string, body io.Reader, contentType *string) (*http.Response, int, error) {
+	if err := gc.checkWritable(method, path); err != nil {
+		return nil, 0, err
+	}
+	ctx, span := gc.startRequestSpan(ctx, method, path)
+	response, retries, err := gc.send(ctx, path, method, body, contentType)
+	err = interrupted(ctx, method, path, err)
+	endRequestSpan(span, response, retries, err)
+	return response, retries, err
+}
+
+func (gc *baseClient) send(ctx context.Context, path string, method string, body io.Reader, contentType *string) (*http.Response, int, error) {
+	token, err := gc.getToken(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	url := gc.baseUrl + path
+	ctx = withRetryAttempts(ctx)
+	if !isIdempotent(method) && path != batchPath && !reconciledConflict(ctx) {
+		ctx = withNotRepeatable(ctx)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.Token)
+	if gc.userAgent != "" {
+		req.Header.Set("User-Agent", gc.userAgent)
+	}
+	req.Header.Set("client-request-id", gc.clientRequestId)
+	if contentType != nil {
+		req.Header.Set("Content-Type", *contentType)
+	}
+
+	retryablereq, err := retryablehttp.FromRequest(req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	response, err := gc.client.Do(retryablereq)
+	return response, RetryAttempts(ctx), err
+}
+
+// checkWritable refuses a request that could change the tenant when the client is read-only.  JSON
+// batches are sent with POST, but only ever contain reads.
+func (gc *baseClient) checkWritable(method string, path string) error {
+	if !gc.config.ReadOnly || method == http.MethodGet || path == batchPath {
+		return nil
+	}
+	return fmt.Errorf("refusing to send Microsoft Graph request %s %s: %w", method, path, b2c.ErrReadOnly)
+}
+
+// interrupted names the request an error interrupted when the context was cancelled or its deadline
+// passed, which may have happened while acquiring a token, waiting for a retry or sending the request.
+func interrupted(ctx context.Context, method string, path string, err error) error {
+	if err == nil || ctx.Err() == nil {
+		return err
+	}
+	return fmt.Errorf("Microsoft Graph request %s %s did not complete: %w", method, path, err)
+}
+
+// isIdempotent reports whether repeating a request has the same effect as sending it once.
+// A retried POST may find that its earlier attempt already succeeded on the server.
+func isIdempotent(method string) bool {
+	return method != http.MethodPost && method != http.MethodPatch
+}
+
+// retriedConflict reports whether a 409 Conflict may have been caused by a retry of a non-idempotent
+// request whose earlier attempt succeeded on the server even though its response was lost.
+func retriedConflict(method string, status int, retries int) bool {
+	return !isIdempotent(method) && status == http.StatusConflict && retries > 0
+}
@@ -0,0 +1,157 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/pkg/b2c"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testTenantID = "00000000-0000-0000-0000-000000000000"
+
+// newTestBaseClient returns a client that sends all Graph requests to handler using a fake credential.
+func newTestBaseClient(t *testing.T, handler http.Handler) (*baseClient, *fakeCredential) {
+	graph := httptest.NewServer(handler)
+	t.Cleanup(graph.Close)
+
+	cred := &fakeCredential{lifetime: time.Hour, now: time.Now}
+	bc, err := newBaseClient(Config{
+		TenantID:      testTenantID,
+		Credential:    cred,
+		GraphEndpoint: graph.URL,
+		UserAgent:     "azureadb2cief-test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bc, cred
+}
+
+func TestNewBaseClientEnvironments(t *testing.T) {
+	cases := map[string]struct {
+		environment string
+		baseUrl     string
+		scope       string
+	}{
+		"default": {
+			baseUrl: "https://graph.microsoft.com/beta",
+			scope:   "https://graph.microsoft.com/.default",
+		},
+		"usgovernment": {
+			environment: "usgovernment",
+			baseUrl:     "https://graph.microsoft.us/beta",
+			scope:       "https://graph.microsoft.us/.default",
+		},
+		"china": {
+			environment: "China",
+			baseUrl:     "https://microsoftgraph.chinacloudapi.cn/beta",
+			scope:       "https://microsoftgraph.chinacloudapi.cn/.default",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			bc, err := newBaseClient(Config{
+				TenantID:    testTenantID,
+				Credential:  &fakeCredential{lifetime: time.Hour, now: time.Now},
+				Environment: c.environment,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bc.baseUrl != c.baseUrl {
+				t.Errorf("expected base URL %s, got %s", c.baseUrl, bc.baseUrl)
+			}
+			if len(bc.scopes) != 1 || bc.scopes[0] != c.scope {
+				t.Errorf("expected scope %s, got %v", c.scope, bc.scopes)
+			}
+		})
+	}
+
+	if _, err := newBaseClient(Config{Credential: &fakeCredential{}, Environment: "mars"}); err == nil {
+		t.Fatal("expected an error for an unknown environment")
+	}
+}
+
+func TestGraphEndpointOverride(t *testing.T) {
+	var gotPath, gotAuth string
+	bc, _ := newTestBaseClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	response, err := bc.doRequest(context.Background(), "/trustFramework/policies/B2C_1A_Test", http.MethodDelete, http.NoBody, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	if gotPath != "/beta/trustFramework/policies/B2C_1A_Test" {
+		t.Errorf("unexpected path %s", gotPath)
+	}
+	if gotAuth != "Bearer token-1" {
+		t.Errorf("unexpected Authorization header %q", gotAuth)
+	}
+	if bc.scopes[0] != "https://graph.microsoft.com/.default" {
+		t.Errorf("expected the Graph scope to be kept for a custom endpoint, got %s", bc.scopes[0])
+	}
+}
+
+func TestRequestHeaders(t *testing.T) {
+	var userAgents, clientRequestIds []string
+	bc, _ := newTestBaseClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents = append(userAgents, r.Header.Get("User-Agent"))
+		clientRequestIds = append(clientRequestIds, r.Header.Get("client-request-id"))
+	}))
+
+	for i := 0; i < 2; i++ {
+		response, err := bc.doRequest(context.Background(), "/organization", http.MethodGet, http.NoBody, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+	}
+
+	if userAgents[0] != "azureadb2cief-test" {
+		t.Fatalf("unexpected User-Agent %q", userAgents[0])
+	}
+	if clientRequestIds[0] == "" || clientRequestIds[0] != clientRequestIds[1] {
+		t.Fatalf("expected every request to share a client-request-id, got %v", clientRequestIds)
+	}
+}
+
+func TestReadOnlyRefusesWrites(t *testing.T) {
+	var methods []string
+	bc, _ := newTestBaseClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method+" "+r.URL.Path)
+	}))
+	bc.config.ReadOnly = true
+
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		_, err := bc.doRequest(context.Background(), "/trustFramework/policies/B2C_1A_Base", method, http.NoBody, nil)
+		if !errors.Is(err, b2c.ErrReadOnly) {
+			t.Fatalf("expected %s to be refused, got %v", method, err)
+		}
+	}
+
+	for _, path := range []string{"/trustFramework/policies", batchPath} {
+		method := http.MethodGet
+		if path == batchPath {
+			method = http.MethodPost
+		}
+		response, err := bc.doRequest(context.Background(), path, method, http.NoBody, nil)
+		if err != nil {
+			t.Fatalf("expected %s %s to be sent, got %v", method, path, err)
+		}
+		response.Body.Close()
+	}
+
+	if !reflect.DeepEqual(methods, []string{"GET /beta/trustFramework/policies", "POST /beta/$batch"}) {
+		t.Fatalf("expected only the reads to reach Microsoft Graph, got %v", methods)
+	}
+}
@@ -0,0 +1,292 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// batchPath is the Microsoft Graph JSON batching endpoint.
+	batchPath = "/$batch"
+
+	// batchMaxRequests is the most requests Microsoft Graph accepts in a single batch.
+	batchMaxRequests = 20
+
+	// batchWindow is how long a read waits for concurrent reads to share its batch.
+	batchWindow = 10 * time.Millisecond
+
+	// batchTimeout bounds a batch holding a request whose context has no deadline.
+	batchTimeout = 5 * time.Minute
+)
+
+type batchResult struct {
+	response *http.Response
+	err      error
+}
+
+type batchItem struct {
+	ctx    context.Context
+	path   string
+	result chan batchResult
+}
+
+// batcher gathers the reads made within a short window and sends them to Microsoft Graph in a
+// single $batch request.  Terraform refreshes resources concurrently, so a refresh of many policies
+// and key sets needs a fraction of the requests.
+type batcher struct {
+	gc      *baseClient
+	window  time.Duration
+	mu      sync.Mutex
+	pending []*batchItem
+	timer   *time.Timer
+}
+
+func newBatcher(gc *baseClient) *batcher {
+	return &batcher{
+		gc:     gc,
+		window: batchWindow,
+	}
+}
+
+// get queues a GET request and waits for its response.
+func (b *batcher) get(ctx context.Context, path string) (*http.Response, error) {
+	item := &batchItem{
+		ctx:    ctx,
+		path:   path,
+		result: make(chan batchResult, 1),
+	}
+
+	b.mu.Lock()
+	b.pending = append(b.pending, item)
+	if len(b.pending) >= batchMaxRequests {
+		items := b.take()
+		b.mu.Unlock()
+		go b.send(items)
+	} else {
+		if b.timer == nil {
+			b.timer = time.AfterFunc(b.window, b.flush)
+		}
+		b.mu.Unlock()
+	}
+
+	select {
+	case result := <-item.result:
+		return result.response, result.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// take removes the pending requests.  b.mu must be held.
+func (b *batcher) take() []*batchItem {
+	items := b.pending
+	b.pending = nil
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	return items
+}
+
+func (b *batcher) flush() {
+	b.mu.Lock()
+	items := b.take()
+	b.mu.Unlock()
+
+	if len(items) > 0 {
+		b.send(items)
+	}
+}
+
+// send sends the requests in a batch.  Requests which can't be answered from the batch, because the
+// batch failed or the request was throttled within it, are sent on their own so they are retried.
+func (b *batcher) send(items []*batchItem) {
+	// The callers of requests whose context is done have stopped waiting.
+	var waiting []*batchItem
+	for _, item := range items {
+		if err := item.ctx.Err(); err != nil {
+			item.result <- batchResult{err: err}
+			continue
+		}
+		waiting = append(waiting, item)
+	}
+	items = waiting
+	if len(items) == 0 {
+		return
+	}
+	if len(items) == 1 {
+		b.sendAlone(items[0])
+		return
+	}
+
+	ctx, cancel := batchContext(items)
+	defer cancel()
+	responses, err := b.gc.sendBatch(ctx, items)
+	if err != nil {
+		log.Printf("[DEBUG] Microsoft Graph batch of %d requests failed, sending them individually: %s", len(items), err)
+	}
+
+	for i, item := range items {
+		if response, ok := responses[strconv.Itoa(i+1)]; ok && !retryableStatus(response.StatusCode) {
+			item.result <- batchResult{response: response}
+			continue
+		}
+		go b.sendAlone(item)
+	}
+}
+
+// batchContext returns the context of a batch.  The batch outlives a caller whose context is done, as
+// it holds other callers' requests, so it is only cancelled once every caller's context is done, and
+// its deadline is the latest deadline of the callers, or batchTimeout if one of them has none.
+func batchContext(items []*batchItem) (context.Context, context.CancelFunc) {
+	var deadline time.Time
+	for _, item := range items {
+		itemDeadline, ok := item.ctx.Deadline()
+		if !ok {
+			deadline = time.Now().Add(batchTimeout)
+			break
+		}
+		if itemDeadline.After(deadline) {
+			deadline = itemDeadline
+		}
+	}
+	ctx, cancel := context.WithDeadline(context.WithoutCancel(items[0].ctx), deadline)
+
+	var mu sync.Mutex
+	remaining := len(items)
+	stops := make([]func() bool, len(items))
+	for i, item := range items {
+		stops[i] = context.AfterFunc(item.ctx, func() {
+			mu.Lock()
+			defer mu.Unlock()
+			remaining--
+			if remaining == 0 {
+				cancel()
+			}
+		})
+	}
+	return ctx, func() {
+		for _, stop := range stops {
+			stop()
+		}
+		cancel()
+	}
+}
+
+func (b *batcher) sendAlone(item *batchItem) {
+	response, err := b.gc.doRequest(item.ctx, item.path, http.MethodGet, http.NoBody, nil)
+	item.result <- batchResult{response: response, err: err}
+}
+
+// retryableStatus reports whether a response in a batch should be retried, as the batch request
+// itself succeeded and will not be.
+func retryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || (status >= 500 && status != http.StatusNotImplemented)
+}
+
+type batchRequest struct {
+	Id     string `json:"id"`
+	Method string `json:"method"`
+	Url    string `json:"url"`
+}
+
+type batchResponse struct {
+	Id      string            `json:"id"`
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers"`
+	Body    json.RawMessage   `json:"body"`
+}
+
+// sendBatch sends GET requests in a $batch request and returns their responses by id.
+func (gc *baseClient) sendBatch(ctx context.Context, items []*batchItem) (map[string]*http.Response, error) {
+	var payload struct {
+		Requests []batchRequest `json:"requests"`
+	}
+	for i, item := range items {
+		payload.Requests = append(payload.Requests, batchRequest{
+			Id:     strconv.Itoa(i + 1),
+			Method: http.MethodGet,
+			Url:    item.path,
+		})
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType := "application/json"
+	response, err := gc.doRequest(withBatchSize(ctx, len(items)), batchPath, http.MethodPost, bytes.NewBuffer(body), &contentType)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, newGraphError(response)
+	}
+
+	defer response.Body.Close()
+	respBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Responses []batchResponse `json:"responses"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("could not parse the batch response: %s", err)
+	}
+
+	responses := make(map[string]*http.Response, len(result.Responses))
+	for _, item := range result.Responses {
+		responses[item.Id] = item.httpResponse()
+	}
+	return responses, nil
+}
+
+// httpResponse turns a response in a batch into an http.Response, so it is handled like any other.
+func (r batchResponse) httpResponse() *http.Response {
+	header := http.Header{}
+	for name, value := range r.Headers {
+		header.Set(name, value)
+	}
+
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", r.Status, http.StatusText(r.Status)),
+		StatusCode: r.Status,
+		Header:     header,
+		Body:       io.NopCloser(bytes.NewReader(r.body(header.Get("Content-Type")))),
+	}
+}
+
+// body returns the body of a response in a batch.  JSON bodies are embedded as JSON, while other
+// bodies, such as policy XML, are base64 encoded strings.
+func (r batchResponse) body(contentType string) []byte {
+	if len(r.Body) == 0 || string(r.Body) == "null" {
+		return nil
+	}
+	if r.Body[0] != '"' {
+		return r.Body
+	}
+
+	var value string
+	if err := json.Unmarshal(r.Body, &value); err != nil {
+		return r.Body
+	}
+	if mediaType, _, _ := mime.ParseMediaType(contentType); !strings.HasSuffix(mediaType, "json") {
+		if decoded, err := base64.StdEncoding.DecodeString(value); err == nil {
+			return decoded
+		}
+	}
+	return []byte(value)
+}
@@ -0,0 +1,384 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/pkg/b2c"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeBatchGraph answers $batch requests by passing every request in the batch to handler, and
+// encodes bodies the way Microsoft Graph does.
+type fakeBatchGraph struct {
+	t        *testing.T
+	handler  http.Handler
+	batches  int32
+	requests int32
+	failing  bool
+}
+
+func (g *fakeBatchGraph) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/beta/$batch" {
+		atomic.AddInt32(&g.requests, 1)
+		g.handler.ServeHTTP(w, r)
+		return
+	}
+
+	atomic.AddInt32(&g.batches, 1)
+	if g.failing {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	var batch struct {
+		Requests []batchRequest `json:"requests"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+		g.t.Fatal(err)
+	}
+	if len(batch.Requests) > batchMaxRequests {
+		g.t.Errorf("batch of %d requests is larger than %d", len(batch.Requests), batchMaxRequests)
+	}
+
+	var result struct {
+		Responses []map[string]interface{} `json:"responses"`
+	}
+	for _, request := range batch.Requests {
+		recorder := httptest.NewRecorder()
+		g.handler.ServeHTTP(recorder, httptest.NewRequest(request.Method, "/beta"+request.Url, http.NoBody))
+
+		headers := map[string]string{}
+		for name := range recorder.Header() {
+			headers[name] = recorder.Header().Get(name)
+		}
+		var body interface{}
+		if strings.Contains(recorder.Header().Get("Content-Type"), "json") {
+			body = json.RawMessage(recorder.Body.Bytes())
+		} else if recorder.Body.Len() > 0 {
+			body = base64.StdEncoding.EncodeToString(recorder.Body.Bytes())
+		}
+		result.Responses = append(result.Responses, map[string]interface{}{
+			"id":      request.Id,
+			"status":  recorder.Code,
+			"headers": headers,
+			"body":    body,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// newBatchingTestClient returns a client that batches reads made within window.
+func newBatchingTestClient(t *testing.T, window time.Duration, handler http.Handler) (*baseClient, *fakeBatchGraph) {
+	graph := &fakeBatchGraph{t: t, handler: handler}
+	bc, _ := newTestBaseClient(t, graph)
+	bc.batcher = newBatcher(bc)
+	bc.batcher.window = window
+	return bc, graph
+}
+
+// tenantHandler serves the policies and key sets of a test tenant.
+func tenantHandler(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/beta/trustframework/policies/B2C_1A_Missing/$value":
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":{"code":"AADB2C","message":"Policy not found","innerError":{"request-id":"missing-request"}}}`))
+	case strings.HasPrefix(r.URL.Path, "/beta/trustframework/policies/"):
+		name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/beta/trustframework/policies/"), "/$value")
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprintf(w, `<TrustFrameworkPolicy PolicyId="%s" />`, name)
+	case strings.HasPrefix(r.URL.Path, "/beta/trustFramework/keySets/"):
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":"%s","keys":[]}`, strings.TrimPrefix(r.URL.Path, "/beta/trustFramework/keySets/"))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestBatchConcurrentReads(t *testing.T) {
+	bc, graph := newBatchingTestClient(t, 200*time.Millisecond, http.HandlerFunc(tenantHandler))
+	policies, keySets := newPolicyClient(bc), newKeySetClient(bc)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 5; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("B2C_1A_Policy%d", i)
+			policy, err := policies.Get(context.Background(), name)
+			if err != nil {
+				errs <- err
+			} else if policy.Policy != fmt.Sprintf(`<TrustFrameworkPolicy PolicyId="%s" />`, name) {
+				errs <- fmt.Errorf("unexpected policy for %s: %s", name, policy.Policy)
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("B2C_1A_KeySet%d", i)
+			keyset, err := keySets.GetKeySet(context.Background(), name)
+			if err != nil {
+				errs <- err
+			} else if *keyset.Id != name {
+				errs <- fmt.Errorf("unexpected key set for %s: %s", name, *keyset.Id)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if graph.batches != 1 || graph.requests != 0 {
+		t.Fatalf("expected a single batch, got %d batches and %d other requests", graph.batches, graph.requests)
+	}
+}
+
+func TestBatchTakesATokenPerRequest(t *testing.T) {
+	bc, graph := newBatchingTestClient(t, 200*time.Millisecond, http.HandlerFunc(tenantHandler))
+	clock := &fakeClock{now: time.Now()}
+	limiter := newRateLimiter(100)
+	limiter.now = clock.Now
+	limiter.last = clock.Now()
+	bc.client.HTTPClient.Transport = &rateLimitedTransport{limiter: limiter, next: bc.client.HTTPClient.Transport}
+	policies := newPolicyClient(bc)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := policies.Get(context.Background(), fmt.Sprintf("B2C_1A_Policy%d", i)); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if graph.batches != 1 {
+		t.Fatalf("expected a single batch, got %d batches and %d other requests", graph.batches, graph.requests)
+	}
+	if limiter.tokens != 95 {
+		t.Fatalf("expected a token to be taken per request of the batch, %v of 100 are left", limiter.tokens)
+	}
+}
+
+func TestBatchItemErrors(t *testing.T) {
+	bc, graph := newBatchingTestClient(t, 200*time.Millisecond, http.HandlerFunc(tenantHandler))
+	policies := newPolicyClient(bc)
+
+	var wg sync.WaitGroup
+	var found, missing error
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, found = policies.Get(context.Background(), "B2C_1A_Found")
+	}()
+	go func() {
+		defer wg.Done()
+		_, missing = policies.Get(context.Background(), "B2C_1A_Missing")
+	}()
+	wg.Wait()
+
+	if found != nil {
+		t.Fatalf("expected the other request in the batch to succeed, got %s", found)
+	}
+	graphErr, ok := b2c.AsGraphError(missing)
+	if !ok || !b2c.IsNotFound(missing) || graphErr.Message != "Policy not found" || graphErr.RequestId != "missing-request" {
+		t.Fatalf("expected the Graph error of the request in the batch, got %v", missing)
+	}
+	if graph.batches != 1 {
+		t.Fatalf("expected a single batch, got %d", graph.batches)
+	}
+}
+
+func TestBatchSplitsLargeBatches(t *testing.T) {
+	bc, graph := newBatchingTestClient(t, 200*time.Millisecond, http.HandlerFunc(tenantHandler))
+	keySets := newKeySetClient(bc)
+
+	var wg sync.WaitGroup
+	for i := 0; i < batchMaxRequests+5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := keySets.GetKeySet(context.Background(), fmt.Sprintf("B2C_1A_KeySet%d", i)); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if graph.batches != 2 {
+		t.Fatalf("expected two batches, got %d", graph.batches)
+	}
+}
+
+func TestBatchRetriesThrottledItems(t *testing.T) {
+	var throttled int32
+	bc, graph := newBatchingTestClient(t, 200*time.Millisecond, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "B2C_1A_Throttled") && atomic.AddInt32(&throttled, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		tenantHandler(w, r)
+	}))
+	keySets := newKeySetClient(bc)
+
+	var wg sync.WaitGroup
+	for _, name := range []string{"B2C_1A_Throttled", "B2C_1A_Other"} {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			keyset, err := keySets.GetKeySet(context.Background(), name)
+			if err != nil {
+				t.Error(err)
+			} else if *keyset.Id != name {
+				t.Errorf("unexpected key set %s", *keyset.Id)
+			}
+		}(name)
+	}
+	wg.Wait()
+
+	if graph.batches != 1 || graph.requests != 1 {
+		t.Fatalf("expected the throttled request to be sent again on its own, got %d batches and %d other requests", graph.batches, graph.requests)
+	}
+}
+
+func TestBatchFailureFallsBackToSingleRequests(t *testing.T) {
+	bc, graph := newBatchingTestClient(t, 200*time.Millisecond, http.HandlerFunc(tenantHandler))
+	graph.failing = true
+	policies := newPolicyClient(bc)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := policies.Get(context.Background(), fmt.Sprintf("B2C_1A_Policy%d", i)); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if graph.batches != 1 || graph.requests != 3 {
+		t.Fatalf("expected the requests to be sent on their own, got %d batches and %d other requests", graph.batches, graph.requests)
+	}
+}
+
+func TestBatchSingleReadIsNotBatched(t *testing.T) {
+	bc, graph := newBatchingTestClient(t, time.Millisecond, http.HandlerFunc(tenantHandler))
+
+	if _, err := newPolicyClient(bc).Get(context.Background(), "B2C_1A_Policy"); err != nil {
+		t.Fatal(err)
+	}
+	if graph.batches != 0 || graph.requests != 1 {
+		t.Fatalf("expected a single request, got %d batches and %d other requests", graph.batches, graph.requests)
+	}
+}
+
+func TestBatchResponseBody(t *testing.T) {
+	xml := []byte(`<TrustFrameworkPolicy PolicyId="B2C_1A_Test" />`)
+	encoded, _ := json.Marshal(base64.StdEncoding.EncodeToString(xml))
+
+	cases := map[string]struct {
+		response batchResponse
+		expected []byte
+	}{
+		"json": {
+			response: batchResponse{Headers: map[string]string{"Content-Type": "application/json"}, Body: json.RawMessage(`{"id":"B2C_1A_Test"}`)},
+			expected: []byte(`{"id":"B2C_1A_Test"}`),
+		},
+		"base64": {
+			response: batchResponse{Headers: map[string]string{"Content-Type": "application/xml"}, Body: encoded},
+			expected: xml,
+		},
+		"empty": {
+			response: batchResponse{},
+			expected: nil,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			body, err := io.ReadAll(c.response.httpResponse().Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(body, c.expected) {
+				t.Fatalf("expected %s, got %s", c.expected, body)
+			}
+		})
+	}
+}
+
+func TestBatchIsCancelledWithItsCallers(t *testing.T) {
+	cancelled := make(chan struct{})
+	bc, _ := newTestBaseClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Requests hang until they are cancelled, which the server only notices once the body is read.
+		io.Copy(io.Discard, r.Body)
+		<-r.Context().Done()
+		if r.URL.Path == "/beta/$batch" {
+			close(cancelled)
+		}
+	}))
+	bc.batcher = newBatcher(bc)
+	bc.batcher.window = 50 * time.Millisecond
+	policies := newPolicyClient(bc)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(100+i*50)*time.Millisecond)
+			defer cancel()
+			if _, err := policies.Get(ctx, fmt.Sprintf("B2C_1A_Policy%d", i)); err == nil {
+				t.Error("expected the read to fail with its context")
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the batch to be cancelled once every caller gave up")
+	}
+}
+
+func TestBatchLeavesOutDoneItems(t *testing.T) {
+	bc, graph := newBatchingTestClient(t, time.Hour, http.HandlerFunc(tenantHandler))
+	done, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	items := []*batchItem{
+		{ctx: done, path: "/trustframework/policies/B2C_1A_Done/$value", result: make(chan batchResult, 1)},
+		{ctx: context.Background(), path: "/trustframework/policies/B2C_1A_Policy/$value", result: make(chan batchResult, 1)},
+	}
+	bc.batcher.send(items)
+
+	if result := <-items[0].result; result.err == nil {
+		t.Fatal("expected the done item to fail without being sent")
+	}
+	if result := <-items[1].result; result.err != nil || result.response.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected result %v", result)
+	}
+	if graph.batches != 0 || graph.requests != 1 {
+		t.Fatalf("expected the remaining item to be sent alone, got %d batches and %d other requests", graph.batches, graph.requests)
+	}
+}
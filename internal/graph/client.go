@@ -0,0 +1,81 @@
+// Package graph sends the Microsoft Graph requests of the policy and key set clients, with retries,
+// rate limiting, request batching and tracing.  It does not depend on Terraform, so that both the
+// provider and the pkg/b2c/msgraph package can use it.
+package graph
+
+import (
+	"context"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/pkg/b2c"
+	"net/http"
+	"strings"
+)
+
+// Config is the configuration of the Microsoft Graph client of a tenant.
+type Config struct {
+	TenantID   string
+	Credential azcore.TokenCredential
+	// Environment is the national cloud of the tenant, the default environment when empty.
+	Environment string
+	// GraphEndpoint, when set, replaces the Microsoft Graph endpoint of the environment.
+	GraphEndpoint        string
+	MaxRetries           int
+	MaxRequestsPerSecond float64
+	BatchRequests        bool
+	UserAgent            string
+	// ReadOnly refuses every request other than GET, so that the tenant cannot be changed.
+	ReadOnly bool
+	// PolicyNamePrefix, when set, replaces the B2C_1A_ prefix of policy names in the tenant, and of
+	// key set names when PrefixKeySets is true.
+	PolicyNamePrefix string
+	PrefixKeySets    bool
+	// WrapTransport, when set, wraps the transport every attempt of a request is sent with, such as
+	// to log the attempts.
+	WrapTransport func(next http.RoundTripper) http.RoundTripper
+}
+
+// Client holds the clients of a tenant.  They share a token cache, a rate limit and batches.
+type Client struct {
+	Policies     b2c.PolicyClient
+	KeySets      b2c.KeySetClient
+	Organization *OrganizationClient
+	base         *baseClient
+}
+
+func New(config Config) (*Client, error) {
+	bc, err := newBaseClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		Policies:     newPolicyClient(bc),
+		KeySets:      newKeySetClient(bc),
+		Organization: newOrganizationClient(bc),
+		base:         bc,
+	}
+	if !isBlank(config.PolicyNamePrefix) {
+		prefix := policyNamePrefix{prefix: strings.TrimSpace(config.PolicyNamePrefix), keySets: config.PrefixKeySets}
+		c.Policies = &prefixedPolicyClient{policyNamePrefix: prefix, next: c.Policies}
+		if config.PrefixKeySets {
+			c.KeySets = &prefixedKeySetClient{policyNamePrefix: prefix, next: c.KeySets}
+		}
+	}
+	return c, nil
+}
+
+// AccessToken returns the access token Microsoft Graph requests are sent with.
+func (c *Client) AccessToken(ctx context.Context) (*azcore.AccessToken, error) {
+	return c.base.getToken(ctx)
+}
+
+func isBlank(s string) bool {
+	return strings.TrimSpace(s) == ""
+}
+
+var (
+	_ b2c.PolicyClient = (*TrustFrameworkPolicyClient)(nil)
+	_ b2c.KeySetClient = (*TrustFrameworkKeySetClient)(nil)
+	_ b2c.PolicyClient = (*prefixedPolicyClient)(nil)
+	_ b2c.KeySetClient = (*prefixedKeySetClient)(nil)
+)
@@ -0,0 +1,65 @@
+package graph
+
+import (
+	"fmt"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"sort"
+	"strings"
+)
+
+// Environment describes the Azure AD authority and Microsoft Graph endpoint of a national cloud.
+type Environment struct {
+	Name          string
+	AuthorityHost string
+	GraphEndpoint string
+}
+
+// DefaultEnvironment is used when no environment is configured.
+const DefaultEnvironment = "global"
+
+// Environments are the supported national clouds, keyed by the value of the provider's environment argument.
+var Environments = map[string]Environment{
+	"global": {
+		Name:          "global",
+		AuthorityHost: string(azidentity.AzurePublicCloud),
+		GraphEndpoint: "https://graph.microsoft.com",
+	},
+	"usgovernment": {
+		Name:          "usgovernment",
+		AuthorityHost: string(azidentity.AzureGovernment),
+		GraphEndpoint: "https://graph.microsoft.us",
+	},
+	"china": {
+		Name:          "china",
+		AuthorityHost: string(azidentity.AzureChina),
+		GraphEndpoint: "https://microsoftgraph.chinacloudapi.cn",
+	},
+}
+
+// EnvironmentNames returns the names of the supported environments in alphabetical order.
+func EnvironmentNames() []string {
+	var names []string
+	for name := range Environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupEnvironment returns the environment named name, or the default environment when name is empty.
+func LookupEnvironment(name string) (Environment, error) {
+	if isBlank(name) {
+		name = DefaultEnvironment
+	}
+	env, ok := Environments[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return Environment{}, fmt.Errorf("unknown environment %q, valid values are %s", name, strings.Join(EnvironmentNames(), ", "))
+	}
+	return env, nil
+}
+
+// graphScope is the .default scope for the Graph endpoint of the environment.  The scope is based on the
+// environment rather than graph_endpoint, since tokens for a custom endpoint are still issued for Graph.
+func (e Environment) graphScope() string {
+	return e.GraphEndpoint + "/.default"
+}
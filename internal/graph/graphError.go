@@ -0,0 +1,59 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/pkg/b2c"
+	"io"
+	"net/http"
+)
+
+// graphErrorEnvelope is the body Microsoft Graph returns for failed requests.
+type graphErrorEnvelope struct {
+	Error struct {
+		Code       string `json:"code"`
+		Message    string `json:"message"`
+		InnerError struct {
+			Date            string `json:"date"`
+			RequestId       string `json:"request-id"`
+			ClientRequestId string `json:"client-request-id"`
+		} `json:"innerError"`
+	} `json:"error"`
+}
+
+// newGraphError reads and closes the body of a failed response.
+func newGraphError(response *http.Response) error {
+	defer response.Body.Close()
+
+	graphErr := &b2c.GraphError{
+		StatusCode:      response.StatusCode,
+		RequestId:       response.Header.Get("request-id"),
+		ClientRequestId: response.Header.Get("client-request-id"),
+		Date:            response.Header.Get("Date"),
+	}
+
+	respBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		graphErr.Message = fmt.Sprintf("could not read response body: %s", err)
+		return graphErr
+	}
+
+	var envelope graphErrorEnvelope
+	if err := json.Unmarshal(respBody, &envelope); err != nil || envelope.Error.Code == "" {
+		graphErr.Body = string(respBody)
+		return graphErr
+	}
+
+	graphErr.Code = envelope.Error.Code
+	graphErr.Message = envelope.Error.Message
+	if inner := envelope.Error.InnerError; inner.RequestId != "" {
+		graphErr.RequestId = inner.RequestId
+	}
+	if inner := envelope.Error.InnerError; inner.ClientRequestId != "" {
+		graphErr.ClientRequestId = inner.ClientRequestId
+	}
+	if inner := envelope.Error.InnerError; inner.Date != "" {
+		graphErr.Date = inner.Date
+	}
+	return graphErr
+}
@@ -0,0 +1,88 @@
+package graph
+
+import (
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/pkg/b2c"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func newErrorResponse(status int, header http.Header, body string) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestNewGraphErrorParsesEnvelope(t *testing.T) {
+	header := http.Header{}
+	header.Set("request-id", "header-request-id")
+	response := newErrorResponse(http.StatusNotFound, header, `{
+		"error": {
+			"code": "AADB2C",
+			"message": "The policy B2C_1A_Missing was not found.",
+			"innerError": {
+				"date": "2021-11-01T10:00:00",
+				"request-id": "5b3a9c2d-0000-4c3e-9d6f-0123456789ab",
+				"client-request-id": "9c1d6e0a-0000-4a1b-8c2d-0123456789ab"
+			}
+		}
+	}`)
+
+	err := newGraphError(response)
+	graphErr, ok := b2c.AsGraphError(err)
+	if !ok {
+		t.Fatalf("expected a GraphError, got %T", err)
+	}
+	if graphErr.StatusCode != http.StatusNotFound || graphErr.Code != "AADB2C" || graphErr.Message != "The policy B2C_1A_Missing was not found." {
+		t.Fatalf("unexpected error %+v", graphErr)
+	}
+	if graphErr.RequestId != "5b3a9c2d-0000-4c3e-9d6f-0123456789ab" {
+		t.Fatalf("expected the inner error request-id to be preferred, got %q", graphErr.RequestId)
+	}
+	if graphErr.ClientRequestId != "9c1d6e0a-0000-4a1b-8c2d-0123456789ab" || graphErr.Date != "2021-11-01T10:00:00" {
+		t.Fatalf("unexpected inner error %+v", graphErr)
+	}
+	if graphErr.Body != "" {
+		t.Fatalf("expected the raw body to be dropped for a parsed envelope, got %q", graphErr.Body)
+	}
+
+	expected := "unexpected status 404 (AADB2C): The policy B2C_1A_Missing was not found. (request-id: 5b3a9c2d-0000-4c3e-9d6f-0123456789ab)"
+	if err.Error() != expected {
+		t.Fatalf("expected %q, got %q", expected, err.Error())
+	}
+}
+
+func TestNewGraphErrorFallsBackToHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Set("request-id", "header-request-id")
+	header.Set("client-request-id", "header-client-request-id")
+	header.Set("Date", "Mon, 01 Nov 2021 10:00:00 GMT")
+	response := newErrorResponse(http.StatusBadGateway, header, "<html>Bad Gateway</html>")
+
+	graphErr, ok := b2c.AsGraphError(newGraphError(response))
+	if !ok {
+		t.Fatal("expected a GraphError")
+	}
+	if graphErr.RequestId != "header-request-id" || graphErr.ClientRequestId != "header-client-request-id" || graphErr.Date != "Mon, 01 Nov 2021 10:00:00 GMT" {
+		t.Fatalf("expected the identifiers from the headers, got %+v", graphErr)
+	}
+	if graphErr.Code != "" || graphErr.Body != "<html>Bad Gateway</html>" {
+		t.Fatalf("expected the raw body to be kept, got %+v", graphErr)
+	}
+	if !strings.Contains(graphErr.Error(), "with response: <html>Bad Gateway</html>") {
+		t.Fatalf("unexpected message %q", graphErr.Error())
+	}
+}
+
+func TestNewGraphErrorWithoutBody(t *testing.T) {
+	err := newGraphError(newErrorResponse(http.StatusForbidden, nil, ""))
+	if err.Error() != "unexpected status 403 received with no body" {
+		t.Fatalf("unexpected message %q", err.Error())
+	}
+}
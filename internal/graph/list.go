@@ -0,0 +1,58 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/pkg/b2c"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// collectionPage is a page of a Microsoft Graph collection.
+type collectionPage[T any] struct {
+	Value    []T    `json:"value"`
+	NextLink string `json:"@odata.nextLink"`
+}
+
+// listPath adds the query of the list options to the path of a collection.
+func listPath(path string, options []b2c.ListOption) string {
+	listOptions := b2c.NewListOptions(options...)
+	if listOptions.PageSize > 0 {
+		path += fmt.Sprintf("?$top=%d", listOptions.PageSize)
+	}
+	return path
+}
+
+// getPage reads the first page of the collection at path, or the page nextLink refers to.  Pages are
+// read with doGet, so they share batches with concurrent reads.
+func getPage[T any](ctx context.Context, gc *baseClient, path string, nextLink string) ([]T, string, error) {
+	if nextLink != "" {
+		// The next link is an absolute URL of the same endpoint.
+		if !strings.HasPrefix(nextLink, gc.baseUrl+"/") {
+			return nil, "", fmt.Errorf("unexpected next page link %q outside %s", nextLink, gc.baseUrl)
+		}
+		path = strings.TrimPrefix(nextLink, gc.baseUrl)
+	}
+
+	response, err := gc.doGet(ctx, path)
+	if err != nil {
+		return nil, "", err
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, "", newGraphError(response)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var page collectionPage[T]
+	if err := json.Unmarshal(body, &page); err != nil {
+		return nil, "", err
+	}
+	return page.Value, page.NextLink, nil
+}
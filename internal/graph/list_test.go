@@ -0,0 +1,86 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/pkg/b2c"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// pagedHandler serves a collection of policies and key sets in pages of two, linked by absolute URLs.
+func pagedHandler(baseUrl *string, queries *[]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*queries = append(*queries, r.URL.RawQuery)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("$skiptoken") {
+		case "":
+			fmt.Fprintf(w, `{"value":[{"id":"B2C_1A_1","keys":[{"kid":"k1","use":"sig","kty":"RSA","status":"enabled","x5c":["MIIC"]}]},{"id":"B2C_1A_2"}],"@odata.nextLink":"%s%s?$skiptoken=2"}`, *baseUrl, strings.TrimPrefix(r.URL.Path, "/beta"))
+		default:
+			fmt.Fprint(w, `{"value":[{"id":"B2C_1A_3"}]}`)
+		}
+	}
+}
+
+func TestPolicyList(t *testing.T) {
+	var baseUrl string
+	var queries []string
+	bc, _ := newTestBaseClient(t, pagedHandler(&baseUrl, &queries))
+	baseUrl = bc.baseUrl
+
+	policies, err := newPolicyClient(bc).List(b2c.WithPageSize(2)).Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []b2c.Policy{{Name: "B2C_1A_1"}, {Name: "B2C_1A_2"}, {Name: "B2C_1A_3"}}
+	if !reflect.DeepEqual(policies, expected) {
+		t.Fatalf("expected %v, got %v", expected, policies)
+	}
+	if !reflect.DeepEqual(queries, []string{"$top=2", "$skiptoken=2"}) {
+		t.Fatalf("expected the page size on the first request and the next link after, got %v", queries)
+	}
+}
+
+func TestKeySetList(t *testing.T) {
+	var baseUrl string
+	var queries []string
+	bc, _ := newTestBaseClient(t, pagedHandler(&baseUrl, &queries))
+	baseUrl = bc.baseUrl
+
+	pager := newKeySetClient(bc).ListKeySets()
+	page, err := pager.NextPage(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 2 || *page[0].Id != "B2C_1A_1" || len(page[0].Keys) != 1 || !pager.More() {
+		t.Fatalf("unexpected first page %+v", page)
+	}
+	key := page[0].Keys[0]
+	if *key.Kid != "k1" || *key.Status != b2c.KeyStatusEnabled || !reflect.DeepEqual(key.X5c, []string{"MIIC"}) {
+		t.Fatalf("unexpected key %+v", key)
+	}
+
+	page, err = pager.NextPage(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 1 || *page[0].Id != "B2C_1A_3" || pager.More() {
+		t.Fatalf("unexpected last page %+v", page)
+	}
+}
+
+func TestListRejectsForeignNextLink(t *testing.T) {
+	var queries []string
+	baseUrl := "https://graph.example.com/beta"
+	bc, _ := newTestBaseClient(t, pagedHandler(&baseUrl, &queries))
+
+	_, err := newPolicyClient(bc).List().Collect(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "graph.example.com") {
+		t.Fatalf("expected the next link outside the endpoint to be rejected, got %v", err)
+	}
+	if len(queries) != 1 {
+		t.Fatalf("expected no request for the next link, got %d requests", len(queries))
+	}
+}
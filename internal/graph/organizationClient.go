@@ -0,0 +1,56 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/models"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// B2CTenantType is the tenantType of an Azure AD B2C organization.
+const B2CTenantType = "AAD B2C"
+
+type OrganizationClient struct {
+	*baseClient
+}
+
+func newOrganizationClient(baseClient *baseClient) *OrganizationClient {
+	return &OrganizationClient{
+		baseClient,
+	}
+}
+
+// Get returns the organization of the configured tenant.
+func (c *OrganizationClient) Get(ctx context.Context) (*models.Organization, error) {
+	response, err := c.doRequest(ctx, "/organization", http.MethodGet, http.NoBody, nil)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, newGraphError(response)
+	}
+
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Value []models.Organization `json:"value"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+
+	for _, org := range result.Value {
+		if strings.EqualFold(org.Id, c.config.TenantID) || len(result.Value) == 1 {
+			return &org, nil
+		}
+	}
+
+	return nil, fmt.Errorf("organization %s was not returned by Microsoft Graph", c.config.TenantID)
+}
@@ -0,0 +1,29 @@
+package graph
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestOrganizationClientGet(t *testing.T) {
+	bc, _ := newTestBaseClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/beta/organization" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"value":[{"id":"` + testTenantID + `","displayName":"Contoso B2C","tenantType":"AAD B2C","verifiedDomains":[{"name":"contoso.onmicrosoft.com","isDefault":true,"isInitial":true}]}]}`))
+	}))
+
+	org, err := newOrganizationClient(bc).Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if org.TenantType != B2CTenantType || org.DisplayName != "Contoso B2C" {
+		t.Fatalf("unexpected organization %+v", org)
+	}
+	if len(org.VerifiedDomains) != 1 || org.VerifiedDomains[0].Name != "contoso.onmicrosoft.com" {
+		t.Fatalf("unexpected verified domains %+v", org.VerifiedDomains)
+	}
+}
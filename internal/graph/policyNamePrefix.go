@@ -0,0 +1,159 @@
+package graph
+
+import (
+	"context"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/util"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/pkg/b2c"
+	"strings"
+)
+
+// policyNamePrefix namespaces the policies, and optionally the key sets, of a configuration in a
+// tenant shared by several developers.  Names are written in the configuration with the B2C_1A_
+// prefix, which is replaced by the namespace's prefix in the tenant.
+type policyNamePrefix struct {
+	prefix  string
+	keySets bool
+}
+
+const defaultPolicyNamePrefix = "B2C_1A_"
+
+// add returns the name of an object in the tenant.  The B2C_1A_ of the name is kept as it was written,
+// and the rest of the prefix is inserted after it, so that remove restores the name exactly.
+func (p policyNamePrefix) add(name string) string {
+	if !hasPrefixFold(name, defaultPolicyNamePrefix) {
+		return name
+	}
+	n := len(defaultPolicyNamePrefix)
+	return name[:n] + p.prefix[n:] + name[n:]
+}
+
+// remove returns the name of an object in the configuration.
+func (p policyNamePrefix) remove(name string) string {
+	if !hasPrefixFold(name, p.prefix) {
+		return name
+	}
+	return name[:len(defaultPolicyNamePrefix)] + name[len(p.prefix):]
+}
+
+// owns reports whether an object of the tenant belongs to the namespace.
+func (p policyNamePrefix) owns(name string) bool {
+	return hasPrefixFold(name, p.prefix)
+}
+
+func hasPrefixFold(s string, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// prefixedPolicyClient renames the policies it reads and writes, and the policies and key sets their
+// XML refers to.  Listing only returns the policies of the namespace.
+type prefixedPolicyClient struct {
+	policyNamePrefix
+	next b2c.PolicyClient
+}
+
+func (c *prefixedPolicyClient) Get(ctx context.Context, name string) (*b2c.Policy, error) {
+	policy, err := c.next.Get(ctx, c.add(name))
+	if err != nil {
+		return nil, err
+	}
+	policyXml, err := util.RenamePolicyIds(policy.Policy, c.remove, c.keySets)
+	if err != nil {
+		return nil, err
+	}
+	return &b2c.Policy{Name: name, Policy: policyXml}, nil
+}
+
+func (c *prefixedPolicyClient) List(options ...b2c.ListOption) *b2c.Pager[b2c.Policy] {
+	return filterPager(c.next.List(options...), func(policy b2c.Policy) (b2c.Policy, bool) {
+		return b2c.Policy{Name: c.remove(policy.Name)}, c.owns(policy.Name)
+	})
+}
+
+func (c *prefixedPolicyClient) Create(ctx context.Context, policyXml *string) error {
+	renamed, err := util.RenamePolicyIds(*policyXml, c.add, c.keySets)
+	if err != nil {
+		return err
+	}
+	return c.next.Create(ctx, &renamed)
+}
+
+func (c *prefixedPolicyClient) Update(ctx context.Context, policy *b2c.Policy) error {
+	renamed, err := util.RenamePolicyIds(policy.Policy, c.add, c.keySets)
+	if err != nil {
+		return err
+	}
+	return c.next.Update(ctx, &b2c.Policy{Name: c.add(policy.Name), Policy: renamed})
+}
+
+func (c *prefixedPolicyClient) Delete(ctx context.Context, name string) error {
+	return c.next.Delete(ctx, c.add(name))
+}
+
+// prefixedKeySetClient renames the key sets it reads and writes.  Listing only returns the key sets
+// of the namespace.
+type prefixedKeySetClient struct {
+	policyNamePrefix
+	next b2c.KeySetClient
+}
+
+func (c *prefixedKeySetClient) removeFromKeySet(keySet *b2c.TrustFrameworkKeySet) *b2c.TrustFrameworkKeySet {
+	if keySet != nil && keySet.Id != nil {
+		id := c.remove(*keySet.Id)
+		keySet.Id = &id
+	}
+	return keySet
+}
+
+func (c *prefixedKeySetClient) GetKeySet(ctx context.Context, id string) (*b2c.TrustFrameworkKeySet, error) {
+	keySet, err := c.next.GetKeySet(ctx, c.add(id))
+	return c.removeFromKeySet(keySet), err
+}
+
+func (c *prefixedKeySetClient) GetActiveKey(ctx context.Context, id string) (*b2c.TrustFrameworkKey, error) {
+	return c.next.GetActiveKey(ctx, c.add(id))
+}
+
+func (c *prefixedKeySetClient) ListKeySets(options ...b2c.ListOption) *b2c.Pager[b2c.TrustFrameworkKeySet] {
+	return filterPager(c.next.ListKeySets(options...), func(keySet b2c.TrustFrameworkKeySet) (b2c.TrustFrameworkKeySet, bool) {
+		owned := keySet.Id != nil && c.owns(*keySet.Id)
+		return *c.removeFromKeySet(&keySet), owned
+	})
+}
+
+func (c *prefixedKeySetClient) CreateKey(ctx context.Context, id string) (*b2c.TrustFrameworkKeySet, error) {
+	keySet, err := c.next.CreateKey(ctx, c.add(id))
+	return c.removeFromKeySet(keySet), err
+}
+
+func (c *prefixedKeySetClient) GenerateKey(ctx context.Context, id string, key b2c.TrustFrameworkKey) error {
+	return c.next.GenerateKey(ctx, c.add(id), key)
+}
+
+func (c *prefixedKeySetClient) UploadSecret(ctx context.Context, id string, key b2c.TrustFrameworkKey) error {
+	return c.next.UploadSecret(ctx, c.add(id), key)
+}
+
+func (c *prefixedKeySetClient) DeleteKey(ctx context.Context, id string) error {
+	return c.next.DeleteKey(ctx, c.add(id))
+}
+
+// filterPager returns a pager over the items of pager that f keeps, converted by f.
+func filterPager[T any](pager *b2c.Pager[T], f func(T) (T, bool)) *b2c.Pager[T] {
+	return b2c.NewPager(func(ctx context.Context, _ string) ([]T, string, error) {
+		items, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, "", err
+		}
+		var kept []T
+		for _, item := range items {
+			if converted, ok := f(item); ok {
+				kept = append(kept, converted)
+			}
+		}
+		if !pager.More() {
+			return kept, "", nil
+		}
+		// The link is not used, the inner pager keeps its own.
+		return kept, "next", nil
+	})
+}
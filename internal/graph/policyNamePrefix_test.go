@@ -0,0 +1,142 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/util"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/pkg/b2c"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestPrefixedPolicyClient(t *testing.T) {
+	const configured = `<TrustFrameworkPolicy PolicyId="B2C_1A_SignUp" PublicPolicyUri="http://contoso.onmicrosoft.com/B2C_1A_SignUp">` +
+		`<BasePolicy><PolicyId>B2C_1A_TrustFrameworkBase</PolicyId></BasePolicy><Key StorageReferenceId="B2C_1A_Secret"/></TrustFrameworkPolicy>`
+	const stored = `<TrustFrameworkPolicy PolicyId="B2C_1A_alice_SignUp" PublicPolicyUri="http://contoso.onmicrosoft.com/B2C_1A_alice_SignUp">` +
+		`<BasePolicy><PolicyId>B2C_1A_alice_TrustFrameworkBase</PolicyId></BasePolicy><Key StorageReferenceId="B2C_1A_Secret"/></TrustFrameworkPolicy>`
+
+	var requests, bodies []string
+	bc, _ := newTestBaseClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		bodies = append(bodies, string(body))
+		switch r.Method {
+		case http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodGet:
+			if r.URL.Path == "/beta/trustFramework/policies" {
+				fmt.Fprint(w, `{"value":[{"id":"B2C_1A_alice_SignUp"},{"id":"B2C_1A_bob_SignUp"},{"id":"B2C_1A_SignUp"}]}`)
+				return
+			}
+			fmt.Fprint(w, stored)
+		}
+	}))
+	policyClient := &prefixedPolicyClient{policyNamePrefix{prefix: "B2C_1A_alice_"}, newPolicyClient(bc)}
+	ctx := context.Background()
+
+	policyXml := configured
+	if err := policyClient.Create(ctx, &policyXml); err != nil {
+		t.Fatal(err)
+	}
+	if bodies[0] != stored {
+		t.Fatalf("expected the policy ids to be prefixed, got %s", bodies[0])
+	}
+
+	policy, err := policyClient.Get(ctx, "B2C_1A_SignUp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.Name != "B2C_1A_SignUp" || policy.Policy != configured {
+		t.Fatalf("expected the prefix to be removed on read, got %+v", policy)
+	}
+
+	policies, err := policyClient.List().Collect(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(policies, []b2c.Policy{{Name: "B2C_1A_SignUp"}}) {
+		t.Fatalf("expected only the policies of the namespace, got %v", policies)
+	}
+
+	if err := policyClient.Delete(ctx, "B2C_1A_SignUp"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"POST /beta/trustFramework/policies",
+		"GET /beta/trustframework/policies/B2C_1A_alice_SignUp/$value",
+		"GET /beta/trustFramework/policies",
+		"DELETE /beta/trustframework/policies/B2C_1A_alice_SignUp",
+	}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Fatalf("expected %v, got %v", expected, requests)
+	}
+}
+
+func TestPolicyNamePrefixKeepsCase(t *testing.T) {
+	p := policyNamePrefix{prefix: "B2C_1A_alice_"}
+	for name, stored := range map[string]string{
+		"B2C_1A_SignUp": "B2C_1A_alice_SignUp",
+		"b2c_1a_SignUp": "b2c_1a_alice_SignUp",
+		"B2c_1A_SignUp": "B2c_1A_alice_SignUp",
+		"SignUp":        "SignUp",
+	} {
+		if added := p.add(name); added != stored {
+			t.Errorf("expected %s to be stored as %s, got %s", name, stored, added)
+		}
+		if removed := p.remove(stored); removed != name {
+			t.Errorf("expected %s to be read as %s, got %s", stored, name, removed)
+		}
+	}
+
+	const configured = `<TrustFrameworkPolicy PolicyId="b2c_1a_SignUp" PublicPolicyUri="http://contoso.onmicrosoft.com/b2c_1a_SignUp">` +
+		`<BasePolicy><PolicyId>b2c_1a_TrustFrameworkBase</PolicyId></BasePolicy></TrustFrameworkPolicy>`
+	stored, err := util.RenamePolicyIds(configured, p.add, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read, err := util.RenamePolicyIds(stored, p.remove, false); err != nil || read != configured {
+		t.Fatalf("expected a lowercase policy to round-trip, got %s (%v)", read, err)
+	}
+}
+
+func TestPrefixedKeySetClient(t *testing.T) {
+	var requests []string
+	bc, _ := newTestBaseClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.Method {
+		case http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"id":"B2C_1A_alice_Secret"}`)
+		default:
+			fmt.Fprint(w, `{"value":[{"id":"B2C_1A_alice_Secret"},{"id":"B2C_1A_Secret"}]}`)
+		}
+	}))
+	keySetClient := &prefixedKeySetClient{policyNamePrefix{prefix: "B2C_1A_alice_", keySets: true}, newKeySetClient(bc)}
+	ctx := context.Background()
+
+	keySet, err := keySetClient.CreateKey(ctx, "B2C_1A_Secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *keySet.Id != "B2C_1A_Secret" {
+		t.Fatalf("expected the prefix to be removed from the created key set, got %s", *keySet.Id)
+	}
+
+	keySets, err := keySetClient.ListKeySets().Collect(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keySets) != 1 || *keySets[0].Id != "B2C_1A_Secret" {
+		t.Fatalf("expected only the key sets of the namespace, got %+v", keySets)
+	}
+
+	expected := []string{"POST /beta/trustFramework/keySets", "GET /beta/trustFramework/keySets"}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Fatalf("expected %v, got %v", expected, requests)
+	}
+}
@@ -0,0 +1,155 @@
+package graph
+
+import (
+	"context"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tenantLimiters are shared by every client for a tenant, so that aliased provider configurations
+// and concurrent resources draw from a single budget.
+var tenantLimiters = struct {
+	sync.Mutex
+	limiters map[string]*rateLimiter
+}{limiters: map[string]*rateLimiter{}}
+
+// tenantRateLimiter returns the shared limiter for a tenant.  When clients for the same tenant are
+// configured with different rates, the lowest rate applies.  A rate of zero or less disables limiting.
+func tenantRateLimiter(tenantID string, requestsPerSecond float64) *rateLimiter {
+	if requestsPerSecond <= 0 {
+		return nil
+	}
+
+	tenantLimiters.Lock()
+	defer tenantLimiters.Unlock()
+
+	key := strings.ToLower(tenantID)
+	limiter, ok := tenantLimiters.limiters[key]
+	if !ok {
+		limiter = newRateLimiter(requestsPerSecond)
+		tenantLimiters.limiters[key] = limiter
+	} else {
+		limiter.lowerRate(requestsPerSecond)
+	}
+	return limiter
+}
+
+// rateLimiter is a token bucket which holds up to one second worth of requests.
+type rateLimiter struct {
+	mu       sync.Mutex
+	rate     float64
+	capacity float64
+	tokens   float64
+	last     time.Time
+	now      func() time.Time
+}
+
+func newRateLimiter(requestsPerSecond float64) *rateLimiter {
+	l := &rateLimiter{now: time.Now}
+	l.setRate(requestsPerSecond)
+	l.tokens = l.capacity
+	l.last = l.now()
+	return l
+}
+
+func (l *rateLimiter) setRate(requestsPerSecond float64) {
+	l.rate = requestsPerSecond
+	l.capacity = requestsPerSecond
+	if l.capacity < 1 {
+		l.capacity = 1
+	}
+}
+
+func (l *rateLimiter) lowerRate(requestsPerSecond float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if requestsPerSecond < l.rate {
+		l.setRate(requestsPerSecond)
+		if l.tokens > l.capacity {
+			l.tokens = l.capacity
+		}
+	}
+}
+
+// reserve takes n tokens and returns how long the caller has to wait before they may be used.
+func (l *rateLimiter) reserve(n int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.capacity {
+		l.tokens = l.capacity
+	}
+	l.last = now
+
+	l.tokens -= float64(n)
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// Wait blocks until a request may be sent or the context is done.
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	return l.WaitN(ctx, 1)
+}
+
+// WaitN blocks until n requests may be sent or the context is done.
+func (l *rateLimiter) WaitN(ctx context.Context, n int) error {
+	wait := l.reserve(n)
+	if wait <= 0 {
+		return nil
+	}
+
+	_, span := tracing.Tracer().Start(ctx, "graph.rate_limit", trace.WithAttributes(
+		attribute.Int64("azureadb2cief.rate_limit.wait_ms", wait.Milliseconds()),
+		attribute.Int("azureadb2cief.rate_limit.requests", n),
+	))
+	defer span.End()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type batchSizeKey struct{}
+
+// withBatchSize marks a $batch request with the number of requests it carries.  Microsoft Graph
+// throttles each of them like a request sent on its own.
+func withBatchSize(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, batchSizeKey{}, n)
+}
+
+// requestCount returns the number of requests an HTTP request counts as against the rate limit.
+func requestCount(ctx context.Context) int {
+	if n, ok := ctx.Value(batchSizeKey{}).(int); ok && n > 0 {
+		return n
+	}
+	return 1
+}
+
+// rateLimitedTransport waits for the limiter before every attempt, including retries.  A $batch
+// request waits for a token per request it carries.
+type rateLimitedTransport struct {
+	limiter *rateLimiter
+	next    http.RoundTripper
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.WaitN(req.Context(), requestCount(req.Context())); err != nil {
+		return nil, err
+	}
+	return t.next.RoundTrip(req)
+}
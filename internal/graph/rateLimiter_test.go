@@ -0,0 +1,53 @@
+package graph
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	limiter := newRateLimiter(2)
+	limiter.now = clock.Now
+	limiter.last = clock.Now()
+
+	for i := 0; i < 2; i++ {
+		if wait := limiter.reserve(1); wait != 0 {
+			t.Fatalf("expected the burst to be allowed, got a wait of %s", wait)
+		}
+	}
+	if wait := limiter.reserve(1); wait != 500*time.Millisecond {
+		t.Fatalf("expected a wait of 500ms, got %s", wait)
+	}
+
+	clock.Advance(10 * time.Second)
+	if wait := limiter.reserve(1); wait != 0 {
+		t.Fatalf("expected tokens to be refilled, got a wait of %s", wait)
+	}
+}
+
+func TestRateLimiterWaitCancelled(t *testing.T) {
+	limiter := newRateLimiter(0.001)
+	limiter.reserve(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected the wait to be cancelled, got %v", err)
+	}
+}
+
+func TestTenantRateLimiterShared(t *testing.T) {
+	a := tenantRateLimiter("Shared-Tenant", 10)
+	b := tenantRateLimiter("shared-tenant", 5)
+	if a != b {
+		t.Fatal("expected clients for the same tenant to share a limiter")
+	}
+	if a.rate != 5 {
+		t.Fatalf("expected the lowest rate to apply, got %v", a.rate)
+	}
+	if tenantRateLimiter("shared-tenant", 0) != nil {
+		t.Fatal("expected no limiter when rate limiting is disabled")
+	}
+}
@@ -0,0 +1,182 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"log"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// maxRetryAfter caps how long a Retry-After header can make a request wait.
+const maxRetryAfter = 5 * time.Minute
+
+type retryAttemptsKey struct{}
+
+// withRetryAttempts adds a counter of the attempts made for a request to the context, so the retry
+// policy can tell whether a failed attempt will actually be retried.
+func withRetryAttempts(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryAttemptsKey{}, new(int32))
+}
+
+// RetryAttempts returns how many times the request using ctx has been retried.
+func RetryAttempts(ctx context.Context) int {
+	if counter, ok := ctx.Value(retryAttemptsKey{}).(*int32); ok {
+		return int(atomic.LoadInt32(counter))
+	}
+	return 0
+}
+
+type reconciledConflictKey struct{}
+
+// withReconciledConflict marks a non-idempotent request whose caller reconciles a 409 Conflict caused by
+// a retry, so it is retried like an idempotent request.
+func withReconciledConflict(ctx context.Context) context.Context {
+	return context.WithValue(ctx, reconciledConflictKey{}, true)
+}
+
+func reconciledConflict(ctx context.Context) bool {
+	reconciled, _ := ctx.Value(reconciledConflictKey{}).(bool)
+	return reconciled
+}
+
+type notRepeatableKey struct{}
+
+// withNotRepeatable marks a request that must not be sent again once Microsoft Graph may have processed
+// it, such as a POST adding a key to a key set, which would add a duplicate key.
+func withNotRepeatable(ctx context.Context) context.Context {
+	return context.WithValue(ctx, notRepeatableKey{}, true)
+}
+
+func notRepeatable(ctx context.Context) bool {
+	notRepeatable, _ := ctx.Value(notRepeatableKey{}).(bool)
+	return notRepeatable
+}
+
+// notProcessed reports whether a failed attempt was certainly not processed by Microsoft Graph: the
+// connection could not be made, or the request was throttled or refused with a Retry-After.
+func notProcessed(resp *http.Response, err error) bool {
+	if err != nil {
+		var opErr *net.OpError
+		var dnsErr *net.DNSError
+		return errors.As(err, &opErr) && opErr.Op == "dial" || errors.As(err, &dnsErr)
+	}
+	return resp != nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable) &&
+		resp.Header.Get("Retry-After") != ""
+}
+
+// graphRetryPolicy retries the same responses as retryablehttp.DefaultRetryPolicy and logs the reason
+// for every retry.
+func graphRetryPolicy(maxRetries int) retryablehttp.CheckRetry {
+	return func(ctx context.Context, resp *http.Response, err error) (bool, error) {
+		retry, checkErr := retryablehttp.DefaultRetryPolicy(ctx, resp, err)
+		if !retry {
+			return retry, checkErr
+		}
+		if notRepeatable(ctx) && !notProcessed(resp, err) {
+			request := ""
+			if resp != nil && resp.Request != nil {
+				request = fmt.Sprintf(" %s %s", resp.Request.Method, resp.Request.URL)
+			}
+			log.Printf("[WARN] Not retrying Microsoft Graph request%s: it is not idempotent and may have been processed", request)
+			return false, checkErr
+		}
+
+		// The policy is also consulted after the last attempt, which is not retried.
+		attempt := 1
+		if counter, ok := ctx.Value(retryAttemptsKey{}).(*int32); ok {
+			if int(atomic.LoadInt32(counter)) >= maxRetries {
+				return retry, checkErr
+			}
+			attempt = int(atomic.AddInt32(counter, 1))
+		} else if maxRetries < 1 {
+			return retry, checkErr
+		}
+
+		reason := "unknown error"
+		if err != nil {
+			reason = err.Error()
+		} else if resp != nil {
+			reason = fmt.Sprintf("status %d", resp.StatusCode)
+			if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+				reason += fmt.Sprintf(", Retry-After %s", retryAfter)
+			}
+			if requestId := resp.Header.Get("request-id"); requestId != "" {
+				reason += fmt.Sprintf(", request-id %s", requestId)
+			}
+		}
+		url := ""
+		if resp != nil && resp.Request != nil {
+			url = fmt.Sprintf(" %s %s", resp.Request.Method, resp.Request.URL)
+			if reconciledConflict(ctx) {
+				reason += ", the request is not idempotent and a conflict will be reconciled"
+			}
+		}
+		log.Printf("[WARN] Retrying Microsoft Graph request%s (retry %d of %d): %s", url, attempt, maxRetries, reason)
+		trace.SpanFromContext(ctx).AddEvent("retry", trace.WithAttributes(
+			tracing.RetryCount.Int(attempt),
+			attribute.String("azureadb2cief.retry.reason", reason),
+		))
+
+		return retry, checkErr
+	}
+}
+
+// graphBackoff waits for the duration of the Retry-After header Microsoft Graph sends with
+// throttled (429) and unavailable (503) responses, and otherwise backs off exponentially.
+// Jitter is added so that requests throttled together don't all retry at the same moment.
+func graphBackoff(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
+	if resp != nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable) {
+		if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			if retryAfter > maxRetryAfter {
+				retryAfter = maxRetryAfter
+			}
+			// Up to 10% extra spreads the retries without ignoring the server's instruction.
+			return retryAfter + jitter(retryAfter/10)
+		}
+	}
+
+	backoff := float64(min) * math.Pow(2, float64(attemptNum))
+	if backoff > float64(max) {
+		backoff = float64(max)
+	}
+	// Full jitter between min and the exponential backoff.
+	return min + jitter(time.Duration(backoff)-min)
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := date.Sub(now); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(max)))
+}
@@ -0,0 +1,146 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/pkg/b2c"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2021, 12, 1, 10, 0, 0, 0, time.UTC)
+	cases := map[string]struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		"seconds":  {value: "7", expected: 7 * time.Second, ok: true},
+		"date":     {value: "Wed, 01 Dec 2021 10:00:30 GMT", expected: 30 * time.Second, ok: true},
+		"past":     {value: "Wed, 01 Dec 2021 09:00:00 GMT", expected: 0, ok: true},
+		"empty":    {value: "", ok: false},
+		"negative": {value: "-1", ok: false},
+		"invalid":  {value: "soon", ok: false},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			wait, ok := parseRetryAfter(c.value, now)
+			if ok != c.ok || wait != c.expected {
+				t.Fatalf("expected (%s, %t), got (%s, %t)", c.expected, c.ok, wait, ok)
+			}
+		})
+	}
+}
+
+func TestGraphBackoff(t *testing.T) {
+	throttled := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {"20"}}}
+	for i := 0; i < 100; i++ {
+		wait := graphBackoff(time.Second, 30*time.Second, 0, throttled)
+		if wait < 20*time.Second || wait >= 22*time.Second {
+			t.Fatalf("expected Retry-After plus up to 10%% jitter, got %s", wait)
+		}
+	}
+
+	tooLong := &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{"Retry-After": {"3600"}}}
+	if wait := graphBackoff(time.Second, 30*time.Second, 0, tooLong); wait >= maxRetryAfter+maxRetryAfter/10 {
+		t.Fatalf("expected Retry-After to be capped, got %s", wait)
+	}
+
+	serverError := &http.Response{StatusCode: http.StatusInternalServerError, Header: http.Header{}}
+	for attempt := 0; attempt < 10; attempt++ {
+		wait := graphBackoff(time.Second, 30*time.Second, attempt, serverError)
+		if wait < time.Second || wait > 30*time.Second {
+			t.Fatalf("attempt %d: expected a backoff between min and max, got %s", attempt, wait)
+		}
+	}
+}
+
+func TestDoRequestRetriesThrottledRequests(t *testing.T) {
+	var calls int
+	bc, _ := newTestBaseClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	bc.client.RetryMax = 3
+	bc.client.CheckRetry = graphRetryPolicy(3)
+
+	response, err := bc.doRequest(context.Background(), "/trustFramework/policies/B2C_1A_Test", http.MethodDelete, http.NoBody, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusNoContent {
+		t.Fatalf("unexpected status %d", response.StatusCode)
+	}
+	if calls != 2 {
+		t.Fatalf("expected 2 calls, got %d", calls)
+	}
+}
+
+func TestDeadlineDuringRetryNamesRequest(t *testing.T) {
+	bc, _ := newTestBaseClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	bc.client.RetryMax = 3
+	bc.client.CheckRetry = graphRetryPolicy(3)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := newPolicyClient(bc).Get(ctx, "B2C_1A_Throttled")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the deadline to end the retries, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "GET /trustframework/policies/B2C_1A_Throttled/$value did not complete") {
+		t.Fatalf("expected the error to name the request, got %s", err)
+	}
+	if waited := time.Since(start); waited > 5*time.Second {
+		t.Fatalf("expected the deadline to interrupt the Retry-After wait, waited %s", waited)
+	}
+}
+
+func TestKeyUploadIsOnlyRetriedWhenNotProcessed(t *testing.T) {
+	cases := map[string]struct {
+		status     int
+		retryAfter string
+		calls      int
+	}{
+		"server error": {status: http.StatusInternalServerError, calls: 1},
+		"throttled":    {status: http.StatusTooManyRequests, retryAfter: "0", calls: 2},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			var calls int
+			bc, _ := newTestBaseClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if calls == 1 {
+					if c.retryAfter != "" {
+						w.Header().Set("Retry-After", c.retryAfter)
+					}
+					w.WriteHeader(c.status)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			bc.client.RetryMax = 3
+			bc.client.CheckRetry = graphRetryPolicy(3)
+
+			err := newKeySetClient(bc).GenerateKey(context.Background(), "B2C_1A_Test", b2c.TrustFrameworkKey{})
+			if calls != c.calls {
+				t.Fatalf("expected %d calls, got %d (%v)", c.calls, calls, err)
+			}
+			if (err == nil) != (c.calls > 1) {
+				t.Fatalf("unexpected error %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package graph
+
+import (
+	"context"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenRefreshWindow is how long before ExpiresOn a cached token is proactively refreshed.
+const tokenRefreshWindow = 5 * time.Minute
+
+// tokenCache wraps a credential and caches its access tokens by tenant and scopes.
+// Credentials such as AzureCLICredential start a new process for every GetToken call,
+// so the cache avoids acquiring a token for every Graph request.  It is safe for concurrent use.
+type tokenCache struct {
+	cred          azcore.TokenCredential
+	refreshWindow time.Duration
+	now           func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*tokenCacheEntry
+}
+
+type tokenCacheEntry struct {
+	// lock is a semaphore rather than a mutex so that waiting for it respects the caller's context.
+	lock  chan struct{}
+	token *azcore.AccessToken
+}
+
+func newTokenCache(cred azcore.TokenCredential) *tokenCache {
+	return &tokenCache{
+		cred:          cred,
+		refreshWindow: tokenRefreshWindow,
+		now:           time.Now,
+		entries:       map[string]*tokenCacheEntry{},
+	}
+}
+
+func (c *tokenCache) GetToken(ctx context.Context, options policy.TokenRequestOptions) (*azcore.AccessToken, error) {
+	entry := c.entry(tokenCacheKey(options))
+
+	// Holding the entry lock while fetching makes concurrent callers for the same
+	// tenant and scopes wait for a single token request instead of issuing their own.
+	select {
+	case entry.lock <- struct{}{}:
+		defer func() { <-entry.lock }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	now := c.now()
+	if entry.token != nil && now.Before(entry.token.ExpiresOn.Add(-c.refreshWindow)) {
+		return entry.token, nil
+	}
+
+	token, err := c.cred.GetToken(ctx, options)
+	if err != nil {
+		// A failed proactive refresh is not fatal while the cached token is still valid.
+		if entry.token != nil && now.Before(entry.token.ExpiresOn) {
+			return entry.token, nil
+		}
+		return nil, err
+	}
+
+	entry.token = token
+	return token, nil
+}
+
+func (c *tokenCache) entry(key string) *tokenCacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &tokenCacheEntry{lock: make(chan struct{}, 1)}
+		c.entries[key] = entry
+	}
+	return entry
+}
+
+func tokenCacheKey(options policy.TokenRequestOptions) string {
+	scopes := make([]string, len(options.Scopes))
+	copy(scopes, options.Scopes)
+	sort.Strings(scopes)
+	return strings.ToLower(options.TenantID) + "|" + strings.Join(scopes, " ")
+}
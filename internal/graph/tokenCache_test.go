@@ -0,0 +1,200 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeCredential struct {
+	calls    int32
+	lifetime time.Duration
+	now      func() time.Time
+	err      error
+	delay    time.Duration
+}
+
+func (f *fakeCredential) GetToken(_ context.Context, _ policy.TokenRequestOptions) (*azcore.AccessToken, error) {
+	n := atomic.AddInt32(&f.calls, 1)
+	if f.delay > 0 {
+		time.Sleep(f.delay)
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &azcore.AccessToken{
+		Token:     fmt.Sprintf("token-%d", n),
+		ExpiresOn: f.now().Add(f.lifetime),
+	}, nil
+}
+
+func (f *fakeCredential) callCount() int {
+	return int(atomic.LoadInt32(&f.calls))
+}
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestTokenCache(cred *fakeCredential, clock *fakeClock) *tokenCache {
+	cred.now = clock.Now
+	cache := newTokenCache(cred)
+	cache.now = clock.Now
+	return cache
+}
+
+var graphTokenOptions = policy.TokenRequestOptions{
+	TenantID: "tenant",
+	Scopes:   []string{"https://graph.microsoft.com/.default"},
+}
+
+func TestTokenCacheReusesToken(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	cred := &fakeCredential{lifetime: time.Hour}
+	cache := newTestTokenCache(cred, clock)
+
+	for i := 0; i < 40; i++ {
+		token, err := cache.GetToken(context.Background(), graphTokenOptions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token.Token != "token-1" {
+			t.Fatalf("expected cached token-1, got %s", token.Token)
+		}
+	}
+
+	if cred.callCount() != 1 {
+		t.Fatalf("expected 1 call to the credential, got %d", cred.callCount())
+	}
+}
+
+func TestTokenCacheRefreshesBeforeExpiry(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	cred := &fakeCredential{lifetime: time.Hour}
+	cache := newTestTokenCache(cred, clock)
+
+	if _, err := cache.GetToken(context.Background(), graphTokenOptions); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(time.Hour - tokenRefreshWindow + time.Second)
+
+	token, err := cache.GetToken(context.Background(), graphTokenOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Token != "token-2" {
+		t.Fatalf("expected refreshed token-2, got %s", token.Token)
+	}
+	if cred.callCount() != 2 {
+		t.Fatalf("expected 2 calls to the credential, got %d", cred.callCount())
+	}
+}
+
+func TestTokenCacheKeyedByTenantAndScopes(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	cred := &fakeCredential{lifetime: time.Hour}
+	cache := newTestTokenCache(cred, clock)
+
+	options := []policy.TokenRequestOptions{
+		graphTokenOptions,
+		{TenantID: "other", Scopes: graphTokenOptions.Scopes},
+		{TenantID: "tenant", Scopes: []string{"https://graph.microsoft.us/.default"}},
+		{TenantID: "TENANT", Scopes: graphTokenOptions.Scopes},
+	}
+	for _, o := range options {
+		if _, err := cache.GetToken(context.Background(), o); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if cred.callCount() != 3 {
+		t.Fatalf("expected 3 calls to the credential, got %d", cred.callCount())
+	}
+}
+
+func TestTokenCacheConcurrentCallers(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	cred := &fakeCredential{lifetime: time.Hour, delay: 20 * time.Millisecond}
+	cache := newTestTokenCache(cred, clock)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cache.GetToken(context.Background(), graphTokenOptions); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if cred.callCount() != 1 {
+		t.Fatalf("expected 1 call to the credential, got %d", cred.callCount())
+	}
+}
+
+func TestTokenCacheRefreshFailure(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	cred := &fakeCredential{lifetime: time.Hour}
+	cache := newTestTokenCache(cred, clock)
+
+	if _, err := cache.GetToken(context.Background(), graphTokenOptions); err != nil {
+		t.Fatal(err)
+	}
+
+	cred.err = errors.New("az cli failed")
+	clock.Advance(time.Hour - time.Minute)
+
+	token, err := cache.GetToken(context.Background(), graphTokenOptions)
+	if err != nil {
+		t.Fatalf("expected the still valid token to be returned, got %s", err)
+	}
+	if token.Token != "token-1" {
+		t.Fatalf("expected token-1, got %s", token.Token)
+	}
+
+	clock.Advance(2 * time.Minute)
+
+	if _, err := cache.GetToken(context.Background(), graphTokenOptions); err == nil {
+		t.Fatal("expected an error once the cached token expired")
+	}
+}
+
+func TestTokenCacheWaitRespectsContext(t *testing.T) {
+	cred := &fakeCredential{lifetime: time.Hour, now: time.Now, delay: 200 * time.Millisecond}
+	cache := newTokenCache(cred)
+
+	go cache.GetToken(context.Background(), graphTokenOptions)
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := cache.GetToken(ctx, graphTokenOptions); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the deadline to end the wait for the token, got %v", err)
+	}
+	if waited := time.Since(start); waited > 150*time.Millisecond {
+		t.Fatalf("expected the wait to end at the deadline, waited %s", waited)
+	}
+}
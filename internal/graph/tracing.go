@@ -0,0 +1,88 @@
+package graph
+
+import (
+	"context"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+)
+
+// startRequestSpan starts the span of a Microsoft Graph request, which covers the token fetch and
+// every attempt including the time spent waiting between retries.
+func (gc *baseClient) startRequestSpan(ctx context.Context, method string, path string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "graph.request", trace.WithAttributes(
+		attribute.String("http.request.method", method),
+		attribute.String("url.path", path),
+		tracing.GraphClientRequestId.String(gc.clientRequestId),
+	))
+}
+
+// getToken fetches the access token for a request in its own span.
+func (gc *baseClient) getToken(ctx context.Context) (*azcore.AccessToken, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "graph.get_token")
+	defer span.End()
+
+	token, err := gc.cred.GetToken(ctx, policy.TokenRequestOptions{
+		TenantID: gc.config.TenantID,
+		Scopes:   gc.scopes,
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return token, err
+}
+
+// endRequestSpan records the outcome of a Microsoft Graph request.
+func endRequestSpan(span trace.Span, response *http.Response, retries int, err error) {
+	defer span.End()
+
+	span.SetAttributes(tracing.RetryCount.Int(retries))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+	recordResponse(span, response)
+}
+
+func recordResponse(span trace.Span, response *http.Response) {
+	span.SetAttributes(attribute.Int("http.response.status_code", response.StatusCode))
+	if requestId := response.Header.Get("request-id"); requestId != "" {
+		span.SetAttributes(tracing.GraphRequestId.String(requestId))
+	}
+	if response.StatusCode >= 400 {
+		span.SetStatus(codes.Error, http.StatusText(response.StatusCode))
+	}
+}
+
+// tracingTransport records a span for every attempt of a request.
+type tracingTransport struct {
+	next http.RoundTripper
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := tracing.Tracer().Start(req.Context(), req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("url.full", req.URL.String()),
+			attribute.String("server.address", req.URL.Hostname()),
+			tracing.RetryCount.Int(RetryAttempts(req.Context())),
+		),
+	)
+	defer span.End()
+
+	response, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return response, err
+	}
+	recordResponse(span, response)
+	return response, nil
+}
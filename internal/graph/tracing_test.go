@@ -0,0 +1,104 @@
+package graph
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// newTestSpanExporter records the spans of the test in memory.
+func newTestSpanExporter(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return exporter
+}
+
+func spanAttribute(span tracetest.SpanStub, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestRequestSpans(t *testing.T) {
+	exporter := newTestSpanExporter(t)
+	attempts := 0
+	bc, _ := newTestBaseClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.Header().Set("request-id", "throttled-request")
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("request-id", "successful-request")
+		w.Write([]byte("<TrustFrameworkPolicy />"))
+	}))
+	bc.client.RetryMax = 1
+	bc.client.CheckRetry = graphRetryPolicy(1)
+	bc.client.Backoff = func(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration { return 0 }
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "azureadb2cief_trust_framework_policy.read")
+	if _, err := newPolicyClient(bc).Get(ctx, "B2C_1A_Test"); err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+
+	spans := map[string][]tracetest.SpanStub{}
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = append(spans[span.Name], span)
+	}
+	if len(spans["graph.request"]) != 1 || len(spans["graph.get_token"]) != 1 || len(spans[http.MethodGet]) != 2 {
+		t.Fatalf("expected a request, token and two attempt spans, got %v", spans)
+	}
+
+	request := spans["graph.request"][0]
+	if request.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Fatal("expected the request span to be a child of the resource span")
+	}
+	if value, _ := spanAttribute(request, "http.response.status_code"); value.AsInt64() != http.StatusOK {
+		t.Fatalf("expected the final status on the request span, got %v", value)
+	}
+	if value, _ := spanAttribute(request, "azureadb2cief.graph.retry_count"); value.AsInt64() != 1 {
+		t.Fatalf("expected one retry on the request span, got %v", value)
+	}
+	if value, _ := spanAttribute(request, "azureadb2cief.graph.client_request_id"); value.AsString() != bc.clientRequestId {
+		t.Fatalf("expected the client-request-id on the request span, got %v", value)
+	}
+	if len(request.Events) != 1 || request.Events[0].Name != "retry" {
+		t.Fatalf("expected a retry event on the request span, got %v", request.Events)
+	}
+
+	if spans["graph.get_token"][0].Parent.SpanID() != request.SpanContext.SpanID() {
+		t.Fatal("expected the token span to be a child of the request span")
+	}
+
+	throttled, succeeded := spans[http.MethodGet][0], spans[http.MethodGet][1]
+	for _, attempt := range spans[http.MethodGet] {
+		if attempt.Parent.SpanID() != request.SpanContext.SpanID() {
+			t.Fatal("expected the attempt spans to be children of the request span")
+		}
+	}
+	if value, _ := spanAttribute(throttled, "azureadb2cief.graph.request_id"); value.AsString() != "throttled-request" {
+		t.Fatalf("expected the request-id of the throttled attempt, got %v", value)
+	}
+	if throttled.Status.Code != codes.Error {
+		t.Fatalf("expected the throttled attempt to be an error, got %v", throttled.Status)
+	}
+	if value, _ := spanAttribute(succeeded, "azureadb2cief.graph.request_id"); value.AsString() != "successful-request" {
+		t.Fatalf("expected the request-id of the successful attempt, got %v", value)
+	}
+	if value, _ := spanAttribute(succeeded, "azureadb2cief.graph.retry_count"); value.AsInt64() != 1 {
+		t.Fatalf("expected the retry count of the successful attempt, got %v", value)
+	}
+}
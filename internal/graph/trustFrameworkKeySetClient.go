@@ -0,0 +1,186 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/pkg/b2c"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+type TrustFrameworkKeySetClient struct {
+	*baseClient
+}
+
+func newKeySetClient(baseClient *baseClient) *TrustFrameworkKeySetClient {
+	return &TrustFrameworkKeySetClient{
+		baseClient,
+	}
+}
+
+func (c *TrustFrameworkKeySetClient) GetKeySet(ctx context.Context, id string) (*b2c.TrustFrameworkKeySet, error) {
+	path := fmt.Sprintf("/trustFramework/keySets/%s", id)
+	response, err := c.doGet(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, newGraphError(response)
+	}
+
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	keyset := b2c.TrustFrameworkKeySet{}
+
+	if err := json.Unmarshal(body, &keyset); err != nil {
+		return nil, err
+	}
+
+	return &keyset, nil
+}
+
+func (c *TrustFrameworkKeySetClient) GetActiveKey(ctx context.Context, id string) (*b2c.TrustFrameworkKey, error) {
+	path := fmt.Sprintf("/trustFramework/keySets/%s/getActiveKey", id)
+	response, err := c.doGet(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, newGraphError(response)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	key := b2c.TrustFrameworkKey{}
+
+	if err := json.Unmarshal(body, &key); err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// ListKeySets returns a pager over the key sets of the tenant.
+func (c *TrustFrameworkKeySetClient) ListKeySets(options ...b2c.ListOption) *b2c.Pager[b2c.TrustFrameworkKeySet] {
+	path := listPath("/trustFramework/keySets", options)
+	return b2c.NewPager(func(ctx context.Context, nextLink string) ([]b2c.TrustFrameworkKeySet, string, error) {
+		return getPage[b2c.TrustFrameworkKeySet](ctx, c.baseClient, path, nextLink)
+	})
+}
+
+func (c *TrustFrameworkKeySetClient) CreateKey(ctx context.Context, id string) (*b2c.TrustFrameworkKeySet, error) {
+	path := "/trustFramework/keySets"
+
+	keyId := map[string]string{
+		"id": id,
+	}
+	body, err := json.Marshal(keyId)
+
+	if err != nil {
+		return nil, err
+	}
+	contentType := "application/json"
+	response, retries, err := c.doRequestCountingRetries(withReconciledConflict(ctx), path, http.MethodPost, bytes.NewBuffer(body), &contentType)
+
+	if err != nil {
+		return nil, err
+	}
+	if retriedConflict(http.MethodPost, response.StatusCode, retries) {
+		return c.reconcileCreateKey(ctx, id, newGraphError(response))
+	}
+	if response.StatusCode != http.StatusCreated {
+		return nil, newGraphError(response)
+	}
+	defer response.Body.Close()
+	body, err = io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	keyset := b2c.TrustFrameworkKeySet{}
+	err = json.Unmarshal(body, &keyset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &keyset, nil
+}
+
+// reconcileCreateKey checks whether a key set that conflicted after a retried create was created by an
+// earlier attempt.  A key set created by this client has no keys yet, as they are added after creation.
+func (c *TrustFrameworkKeySetClient) reconcileCreateKey(ctx context.Context, id string, conflict error) (*b2c.TrustFrameworkKeySet, error) {
+	log.Printf("[INFO] Trust Framework Key Set %q conflicted after a retried create, comparing it with the key set in the tenant", id)
+	keyset, err := c.GetKeySet(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("key set %s create conflicted after a retry and the existing key set could not be read (%s): %w", id, err, conflict)
+	}
+	if keyset.Id == nil || !strings.EqualFold(*keyset.Id, id) || len(keyset.Keys) > 0 {
+		return nil, fmt.Errorf("key set %s already exists in the tenant with %d key(s): %w", id, len(keyset.Keys), conflict)
+	}
+
+	log.Printf("[INFO] Trust Framework Key Set %q was created by an earlier attempt", id)
+	return keyset, nil
+}
+
+func (c *TrustFrameworkKeySetClient) GenerateKey(ctx context.Context, id string, key b2c.TrustFrameworkKey) error {
+	path := fmt.Sprintf("/trustFramework/keySets/%s/generateKey", id)
+
+	body, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	contentType := "application/json"
+	response, err := c.doRequest(ctx, path, http.MethodPost, bytes.NewBuffer(body), &contentType)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		return newGraphError(response)
+	}
+	response.Body.Close()
+	return nil
+}
+func (c *TrustFrameworkKeySetClient) UploadSecret(ctx context.Context, id string, key b2c.TrustFrameworkKey) error {
+	path := fmt.Sprintf("/trustFramework/keySets/%s/uploadSecret", id)
+
+	body, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	contentType := "application/json"
+	response, err := c.doRequest(ctx, path, http.MethodPost, bytes.NewBuffer(body), &contentType)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		return newGraphError(response)
+	}
+	response.Body.Close()
+	return nil
+}
+func (c *TrustFrameworkKeySetClient) DeleteKey(ctx context.Context, id string) error {
+	path := fmt.Sprintf("/trustFramework/keySets/%s", id)
+	response, err := c.doRequest(ctx, path, http.MethodDelete, http.NoBody, nil)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusNoContent {
+		return newGraphError(response)
+	}
+	response.Body.Close()
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/util"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/pkg/b2c"
+	"io"
+	"log"
+	"net/http"
+)
+
+type TrustFrameworkPolicyClient struct {
+	*baseClient
+}
+
+func newPolicyClient(baseClient *baseClient) *TrustFrameworkPolicyClient {
+	return &TrustFrameworkPolicyClient{
+		baseClient,
+	}
+}
+
+func (c *TrustFrameworkPolicyClient) Get(ctx context.Context, name string) (*b2c.Policy, error) {
+	path := fmt.Sprintf("/trustframework/policies/%s/$value", name)
+	response, err := c.doGet(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, newGraphError(response)
+	}
+
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	xml := string(body)
+
+	return &b2c.Policy{
+		Name:   name,
+		Policy: xml,
+	}, nil
+}
+
+// List returns a pager over the policies of the tenant.  Microsoft Graph only returns their names.
+func (c *TrustFrameworkPolicyClient) List(options ...b2c.ListOption) *b2c.Pager[b2c.Policy] {
+	path := listPath("/trustFramework/policies", options)
+	return b2c.NewPager(func(ctx context.Context, nextLink string) ([]b2c.Policy, string, error) {
+		items, next, err := getPage[struct {
+			Id string `json:"id"`
+		}](ctx, c.baseClient, path, nextLink)
+		if err != nil {
+			return nil, "", err
+		}
+
+		policies := make([]b2c.Policy, len(items))
+		for i, item := range items {
+			policies[i] = b2c.Policy{Name: item.Id}
+		}
+		return policies, next, nil
+	})
+}
+
+func (c *TrustFrameworkPolicyClient) Create(ctx context.Context, policyXml *string) error {
+	url := "/trustFramework/policies"
+	body := bytes.NewBuffer([]byte(*policyXml))
+	contentType := "application/xml"
+	response, retries, err := c.doRequestCountingRetries(withReconciledConflict(ctx), url, http.MethodPost, body, &contentType)
+	if err != nil {
+		return err
+	}
+	if retriedConflict(http.MethodPost, response.StatusCode, retries) {
+		conflict := newGraphError(response)
+		return c.reconcileCreate(ctx, *policyXml, conflict)
+	}
+	if response.StatusCode != http.StatusCreated {
+		return newGraphError(response)
+	}
+	response.Body.Close()
+
+	return nil
+}
+
+// reconcileCreate checks whether a policy that conflicted after a retried create was created by an
+// earlier attempt, by comparing the policy in the tenant with the policy that was sent.
+func (c *TrustFrameworkPolicyClient) reconcileCreate(ctx context.Context, policyXml string, conflict error) error {
+	sent, err := util.ParseTrustFrameworkPolicy(policyXml)
+	if err != nil {
+		return fmt.Errorf("policy create conflicted after a retry and could not be reconciled: %w", conflict)
+	}
+
+	log.Printf("[INFO] Trust Framework Policy %q conflicted after a retried create, comparing it with the policy in the tenant", sent.PolicyId)
+	existing, err := c.Get(ctx, sent.PolicyId)
+	if err != nil {
+		return fmt.Errorf("policy %s create conflicted after a retry and the existing policy could not be read (%s): %w", sent.PolicyId, err, conflict)
+	}
+	if !util.EqualXml(existing.Policy, policyXml) {
+		return fmt.Errorf("policy %s already exists in the tenant with different content: %w", sent.PolicyId, conflict)
+	}
+
+	log.Printf("[INFO] Trust Framework Policy %q was created by an earlier attempt", sent.PolicyId)
+	return nil
+}
+
+func (c *TrustFrameworkPolicyClient) Update(ctx context.Context, policy *b2c.Policy) error {
+	path := fmt.Sprintf("/trustframework/policies/%s/$value", policy.Name)
+	contentType := "application/xml"
+	response, err := c.doRequest(ctx, path, http.MethodPut, bytes.NewBuffer([]byte(policy.Policy)), &contentType)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusCreated && response.StatusCode != http.StatusOK {
+		return newGraphError(response)
+	}
+	response.Body.Close()
+
+	return nil
+}
+
+func (c *TrustFrameworkPolicyClient) Delete(ctx context.Context, name string) error {
+	path := fmt.Sprintf("/trustframework/policies/%s", name)
+	response, err := c.doRequest(ctx, path, http.MethodDelete, http.NoBody, nil)
+
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusNoContent {
+		return newGraphError(response)
+	}
+	response.Body.Close()
+
+	return nil
+}
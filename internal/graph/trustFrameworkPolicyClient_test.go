@@ -0,0 +1,103 @@
+package graph
+
+import (
+	"context"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/pkg/b2c"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testPolicyXml = `<TrustFrameworkPolicy xmlns="http://schemas.microsoft.com/online/cpim/schemas/2013/06" PolicySchemaVersion="0.3.0.0" TenantId="contoso.onmicrosoft.com" PolicyId="B2C_1A_Test" PublicPolicyUri="http://contoso.onmicrosoft.com/B2C_1A_Test"><BuildingBlocks /></TrustFrameworkPolicy>`
+
+// newLostResponseServer returns a handler that creates the object on the first POST but fails with
+// a 500 as if the response was lost, and answers every later POST with 409 Conflict.
+func newLostResponseServer(t *testing.T, existing string) http.HandlerFunc {
+	var posts int
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			posts++
+			if posts == 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"error":{"code":"AADB2C","message":"already exists"}}`))
+		case http.MethodGet:
+			w.Write([]byte(existing))
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+	}
+}
+
+func newTestRetryingClient(t *testing.T, handler http.Handler) *baseClient {
+	bc, _ := newTestBaseClient(t, handler)
+	bc.client.RetryMax = 2
+	bc.client.RetryWaitMin = time.Millisecond
+	bc.client.RetryWaitMax = 5 * time.Millisecond
+	bc.client.CheckRetry = graphRetryPolicy(2)
+	return bc
+}
+
+func TestPolicyCreateReconcilesRetriedConflict(t *testing.T) {
+	// The tenant returns the policy formatted differently from what was sent.
+	existing := strings.Replace(testPolicyXml, "><BuildingBlocks />", ">\n  <BuildingBlocks></BuildingBlocks>\n", 1)
+	bc := newTestRetryingClient(t, newLostResponseServer(t, existing))
+
+	xml := testPolicyXml
+	err := newPolicyClient(bc).Create(context.Background(), &xml)
+	if err != nil {
+		t.Fatalf("expected the conflict to be reconciled, got %s", err)
+	}
+}
+
+func TestPolicyCreateRetriedConflictWithDifferentPolicy(t *testing.T) {
+	existing := strings.Replace(testPolicyXml, "<BuildingBlocks />", "<ClaimsProviders />", 1)
+	bc := newTestRetryingClient(t, newLostResponseServer(t, existing))
+
+	xml := testPolicyXml
+	err := newPolicyClient(bc).Create(context.Background(), &xml)
+	if err == nil || !strings.Contains(err.Error(), "already exists in the tenant with different content") {
+		t.Fatalf("expected a conflict error, got %v", err)
+	}
+	if !b2c.IsConflict(err) {
+		t.Fatalf("expected the error to wrap the 409 response, got %v", err)
+	}
+}
+
+func TestPolicyCreateConflictWithoutRetry(t *testing.T) {
+	bc := newTestRetryingClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected no reconciliation without a retry, got %s", r.Method)
+		}
+		io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusConflict)
+	}))
+
+	xml := testPolicyXml
+	err := newPolicyClient(bc).Create(context.Background(), &xml)
+	if !b2c.IsConflict(err) {
+		t.Fatalf("expected a 409 error, got %v", err)
+	}
+}
+
+func TestKeySetCreateReconcilesRetriedConflict(t *testing.T) {
+	bc := newTestRetryingClient(t, newLostResponseServer(t, `{"id":"B2C_1A_TestKeySet","keys":[]}`))
+
+	keyset, err := newKeySetClient(bc).CreateKey(context.Background(), "B2C_1A_TestKeySet")
+	if err != nil {
+		t.Fatalf("expected the conflict to be reconciled, got %s", err)
+	}
+	if *keyset.Id != "B2C_1A_TestKeySet" {
+		t.Fatalf("unexpected key set %s", *keyset.Id)
+	}
+
+	bc = newTestRetryingClient(t, newLostResponseServer(t, `{"id":"B2C_1A_TestKeySet","keys":[{"kty":"RSA","use":"sig"}]}`))
+	if _, err := newKeySetClient(bc).CreateKey(context.Background(), "B2C_1A_TestKeySet"); err == nil {
+		t.Fatal("expected a key set with keys to be a real conflict")
+	}
+}
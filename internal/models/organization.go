@@ -0,0 +1,14 @@
+package models
+
+type Organization struct {
+	Id              string           `json:"id"`
+	DisplayName     string           `json:"displayName"`
+	TenantType      string           `json:"tenantType"`
+	VerifiedDomains []VerifiedDomain `json:"verifiedDomains"`
+}
+
+type VerifiedDomain struct {
+	Name      string `json:"name"`
+	IsDefault bool   `json:"isDefault"`
+	IsInitial bool   `json:"isInitial"`
+}
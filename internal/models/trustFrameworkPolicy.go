@@ -1,6 +0,0 @@
-package models
-
-type Policy struct {
-	Name   string
-	Policy string
-}
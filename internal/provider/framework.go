@@ -0,0 +1,232 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	fwschema "github.com/hashicorp/terraform-plugin-framework/provider/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/datasources"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/functions"
+)
+
+var _ provider.ProviderWithFunctions = &frameworkProvider{}
+
+// frameworkProvider serves the data sources and resources which have moved to the plugin framework.
+// It is served with the SDK provider by the mux server, shares its schema and uses the client the
+// SDK provider configures, so the provider arguments are read and validated in one place.
+type frameworkProvider struct {
+	version     string
+	sdkProvider *schema.Provider
+}
+
+func newFrameworkProvider(version string, sdkProvider *schema.Provider) provider.Provider {
+	return &frameworkProvider{
+		version:     version,
+		sdkProvider: sdkProvider,
+	}
+}
+
+func (p *frameworkProvider) Metadata(_ context.Context, _ provider.MetadataRequest, resp *provider.MetadataResponse) {
+	resp.TypeName = "azureadb2cief"
+	resp.Version = p.version
+}
+
+func (p *frameworkProvider) Schema(ctx context.Context, _ provider.SchemaRequest, resp *provider.SchemaResponse) {
+	s, err := frameworkSchema(ctx, p.sdkProvider)
+	if err != nil {
+		resp.Diagnostics.AddError("Could not convert the provider schema", err.Error())
+		return
+	}
+	resp.Schema = s
+}
+
+// Configure hands the client to the framework data sources and resources.  The mux server configures
+// the SDK provider first, so its client is ready.
+func (p *frameworkProvider) Configure(_ context.Context, _ provider.ConfigureRequest, resp *provider.ConfigureResponse) {
+	meta := p.sdkProvider.Meta()
+	resp.DataSourceData = meta
+	resp.ResourceData = meta
+}
+
+func (p *frameworkProvider) DataSources(_ context.Context) []func() datasource.DataSource {
+	return []func() datasource.DataSource{
+		datasources.NewClientConfigDataSource,
+	}
+}
+
+func (p *frameworkProvider) Resources(_ context.Context) []func() resource.Resource {
+	return nil
+}
+
+func (p *frameworkProvider) Functions(_ context.Context) []func() function.Function {
+	return []func() function.Function{
+		functions.NewCanonicalXmlFunction,
+		functions.NewPolicyIdFunction,
+		functions.NewBasePolicyIdFunction,
+		functions.NewReferencedKeySetsFunction,
+	}
+}
+
+// frameworkSchema converts the schema of the SDK provider, as it is sent to Terraform, to the plugin
+// framework.  The mux server requires the providers it serves to have identical schemas.
+func frameworkSchema(ctx context.Context, sdkProvider *schema.Provider) (fwschema.Schema, error) {
+	resp, err := schema.NewGRPCProviderServer(sdkProvider).GetProviderSchema(ctx, &tfprotov5.GetProviderSchemaRequest{})
+	if err != nil {
+		return fwschema.Schema{}, err
+	}
+	for _, d := range resp.Diagnostics {
+		if d.Severity == tfprotov5.DiagnosticSeverityError {
+			return fwschema.Schema{}, fmt.Errorf("%s: %s", d.Summary, d.Detail)
+		}
+	}
+
+	attributes, blocks, err := frameworkBlock(resp.Provider.Block)
+	if err != nil {
+		return fwschema.Schema{}, err
+	}
+	return fwschema.Schema{
+		Attributes:          attributes,
+		Blocks:              blocks,
+		Description:         plainDescription(resp.Provider.Block.Description, resp.Provider.Block.DescriptionKind),
+		MarkdownDescription: markdownDescription(resp.Provider.Block.Description, resp.Provider.Block.DescriptionKind),
+		DeprecationMessage:  deprecationMessage(resp.Provider.Block.Deprecated),
+	}, nil
+}
+
+func frameworkBlock(block *tfprotov5.SchemaBlock) (map[string]fwschema.Attribute, map[string]fwschema.Block, error) {
+	attributes := map[string]fwschema.Attribute{}
+	for _, a := range block.Attributes {
+		attribute, err := frameworkAttribute(a)
+		if err != nil {
+			return nil, nil, err
+		}
+		attributes[a.Name] = attribute
+	}
+
+	blocks := map[string]fwschema.Block{}
+	for _, b := range block.BlockTypes {
+		if b.Nesting != tfprotov5.SchemaNestedBlockNestingModeList {
+			return nil, nil, fmt.Errorf("block %s has unsupported nesting %s", b.TypeName, b.Nesting)
+		}
+		nestedAttributes, nestedBlocks, err := frameworkBlock(b.Block)
+		if err != nil {
+			return nil, nil, err
+		}
+		blocks[b.TypeName] = fwschema.ListNestedBlock{
+			NestedObject: fwschema.NestedBlockObject{
+				Attributes: nestedAttributes,
+				Blocks:     nestedBlocks,
+			},
+			Description:         plainDescription(b.Block.Description, b.Block.DescriptionKind),
+			MarkdownDescription: markdownDescription(b.Block.Description, b.Block.DescriptionKind),
+			DeprecationMessage:  deprecationMessage(b.Block.Deprecated),
+		}
+	}
+	return attributes, blocks, nil
+}
+
+func frameworkAttribute(a *tfprotov5.SchemaAttribute) (fwschema.Attribute, error) {
+	description := plainDescription(a.Description, a.DescriptionKind)
+	markdown := markdownDescription(a.Description, a.DescriptionKind)
+	deprecation := deprecationMessage(a.Deprecated)
+
+	switch {
+	case a.Type.Is(tftypes.String):
+		return fwschema.StringAttribute{
+			Required:            a.Required,
+			Optional:            a.Optional,
+			Sensitive:           a.Sensitive,
+			Description:         description,
+			MarkdownDescription: markdown,
+			DeprecationMessage:  deprecation,
+		}, nil
+	case a.Type.Is(tftypes.Bool):
+		return fwschema.BoolAttribute{
+			Required:            a.Required,
+			Optional:            a.Optional,
+			Sensitive:           a.Sensitive,
+			Description:         description,
+			MarkdownDescription: markdown,
+			DeprecationMessage:  deprecation,
+		}, nil
+	case a.Type.Is(tftypes.Number):
+		return fwschema.NumberAttribute{
+			Required:            a.Required,
+			Optional:            a.Optional,
+			Sensitive:           a.Sensitive,
+			Description:         description,
+			MarkdownDescription: markdown,
+			DeprecationMessage:  deprecation,
+		}, nil
+	case a.Type.Is(tftypes.Set{}):
+		elementType, err := frameworkElementType(a.Type.(tftypes.Set).ElementType)
+		if err != nil {
+			return nil, fmt.Errorf("attribute %s: %w", a.Name, err)
+		}
+		return fwschema.SetAttribute{
+			ElementType:         elementType,
+			Required:            a.Required,
+			Optional:            a.Optional,
+			Sensitive:           a.Sensitive,
+			Description:         description,
+			MarkdownDescription: markdown,
+			DeprecationMessage:  deprecation,
+		}, nil
+	case a.Type.Is(tftypes.List{}):
+		elementType, err := frameworkElementType(a.Type.(tftypes.List).ElementType)
+		if err != nil {
+			return nil, fmt.Errorf("attribute %s: %w", a.Name, err)
+		}
+		return fwschema.ListAttribute{
+			ElementType:         elementType,
+			Required:            a.Required,
+			Optional:            a.Optional,
+			Sensitive:           a.Sensitive,
+			Description:         description,
+			MarkdownDescription: markdown,
+			DeprecationMessage:  deprecation,
+		}, nil
+	}
+	return nil, fmt.Errorf("attribute %s has unsupported type %s", a.Name, a.Type)
+}
+
+func frameworkElementType(t tftypes.Type) (attr.Type, error) {
+	switch {
+	case t.Is(tftypes.String):
+		return types.StringType, nil
+	case t.Is(tftypes.Bool):
+		return types.BoolType, nil
+	case t.Is(tftypes.Number):
+		return types.NumberType, nil
+	}
+	return nil, fmt.Errorf("unsupported element type %s", t)
+}
+
+func plainDescription(description string, kind tfprotov5.StringKind) string {
+	if kind == tfprotov5.StringKindPlain {
+		return description
+	}
+	return ""
+}
+
+func markdownDescription(description string, kind tfprotov5.StringKind) string {
+	if kind == tfprotov5.StringKindMarkdown {
+		return description
+	}
+	return ""
+}
+
+func deprecationMessage(deprecated bool) string {
+	if deprecated {
+		return "Deprecated"
+	}
+	return ""
+}
@@ -0,0 +1,42 @@
+package provider
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/client"
+)
+
+func policyHistorySchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Optional:    true,
+		MaxItems:    1,
+		Description: "Keeps the XML of policies before they are overwritten in a local directory, to roll back to with `rollback_to`",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"directory": {
+					Type:         schema.TypeString,
+					Required:     true,
+					ValidateFunc: validation.StringIsNotWhiteSpace,
+					Description:  "The directory the versions are written to, as `<directory>/<policy name>/<version>.xml`",
+				},
+				"max_versions": {
+					Type:         schema.TypeInt,
+					Optional:     true,
+					Default:      10,
+					ValidateFunc: validation.IntAtLeast(0),
+					Description:  "The number of versions kept per policy, the oldest are removed.  Set to `0` to keep every version",
+				},
+			},
+		},
+	}
+}
+
+func expandPolicyHistory(raw []interface{}, config *client.MsGraphClientConfig) {
+	if len(raw) == 0 || raw[0] == nil {
+		return
+	}
+	m := raw[0].(map[string]interface{})
+	config.PolicyHistoryDirectory = m["directory"].(string)
+	config.PolicyHistoryMaxVersions = m["max_versions"].(int)
+}
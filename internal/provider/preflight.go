@@ -0,0 +1,173 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/client"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/graph"
+	"strings"
+)
+
+// defaultRequiredPermissions are the permissions needed to manage every resource of the provider.  The
+// provider is configured before it knows which resources the configuration has, so they are only
+// checked with a warning.
+var defaultRequiredPermissions = []string{
+	client.PolicyReadWritePermission,
+	client.KeySetReadWritePermission,
+}
+
+func preflightSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Optional:    true,
+		MaxItems:    1,
+		Description: "Checks run when the provider is configured, before any plan work starts",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"check_permissions": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					Default:     true,
+					Description: "Check that the access token grants the `required_permissions`",
+				},
+				"required_permissions": {
+					Type:     schema.TypeSet,
+					Optional: true,
+					Elem:     &schema.Schema{Type: schema.TypeString},
+					Description: fmt.Sprintf("The Microsoft Graph permissions the configured resources need, which are reported with an error when they are missing.  "+
+						"Defaults to `%s`, which are only reported with a warning", strings.Join(defaultRequiredPermissions, "`, `")),
+				},
+				"check_b2c_tenant": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					Default:     true,
+					Description: "Check that the tenant is an Azure AD B2C tenant",
+				},
+			},
+		},
+	}
+}
+
+type preflightOptions struct {
+	checkPermissions    bool
+	requiredPermissions []string
+	// permissionsSet is whether required_permissions is set, rather than defaulted.
+	permissionsSet bool
+	checkB2CTenant bool
+}
+
+func expandPreflight(raw []interface{}) *preflightOptions {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	options := &preflightOptions{
+		checkPermissions: true,
+		checkB2CTenant:   true,
+	}
+	// An empty block is decoded as nil.
+	if m, ok := raw[0].(map[string]interface{}); ok {
+		options.checkPermissions = m["check_permissions"].(bool)
+		options.checkB2CTenant = m["check_b2c_tenant"].(bool)
+		if permissions, ok := m["required_permissions"].(*schema.Set); ok {
+			for _, p := range permissions.List() {
+				options.requiredPermissions = append(options.requiredPermissions, p.(string))
+			}
+		}
+	}
+	options.permissionsSet = len(options.requiredPermissions) > 0
+	if !options.permissionsSet {
+		options.requiredPermissions = defaultRequiredPermissions
+	}
+	return options
+}
+
+func runPreflight(ctx context.Context, c *client.Client, options *preflightOptions) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if options.checkPermissions {
+		granted, err := c.GrantedPermissions(ctx)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "Could not verify Microsoft Graph permissions",
+				Detail:   err.Error(),
+			})
+		} else {
+			diags = append(diags, permissionDiagnostics(c.Config, granted, options.requiredPermissions, options.permissionsSet)...)
+		}
+	}
+
+	if options.checkB2CTenant {
+		org, err := c.OrganizationClient.Get(ctx)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "Could not verify the tenant type",
+				Detail:   fmt.Sprintf("Reading /organization for tenant %s failed: %s", c.Config.TenantID, err),
+			})
+		} else if !strings.EqualFold(org.TenantType, graph.B2CTenantType) {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Tenant is not an Azure AD B2C tenant",
+				Detail: fmt.Sprintf("Tenant %s (%s) has tenant type %q.  Custom policies and key sets can only be managed in %q tenants, check tenant_id.",
+					c.Config.TenantID, org.DisplayName, org.TenantType, graph.B2CTenantType),
+			})
+		}
+	}
+
+	return diags
+}
+
+// permissionDiagnostics reports the required permissions missing from the granted permissions, with an
+// error when they were set in the configuration and otherwise with a warning, since the default
+// permissions are only needed to write.
+func permissionDiagnostics(config *client.MsGraphClientConfig, granted []string, required []string, requiredSet bool) diag.Diagnostics {
+	missing := missingPermissions(granted, required)
+	if len(missing) == 0 {
+		return nil
+	}
+
+	grantedText := "none"
+	if len(granted) > 0 {
+		grantedText = strings.Join(granted, ", ")
+	}
+	principal := "The signed in principal"
+	if config.ClientID != "" {
+		principal = fmt.Sprintf("Client %s", config.ClientID)
+	}
+	detail := fmt.Sprintf("%s is missing the %s permission(s) in tenant %s, so requests to manage the configured resources would fail with 403 Forbidden.\n\nGranted permissions: %s\n\nGrant the missing Microsoft Graph permissions and admin consent, then acquire a new token.",
+		principal, strings.Join(missing, ", "), config.TenantID, grantedText)
+	severity := diag.Error
+	if !requiredSet {
+		severity = diag.Warning
+		detail += "\n\nThe provider checks the permissions needed to manage every resource.  Configurations that only read, for example with the azureadb2cief_client_config data source, do not need them.  " +
+			"Set required_permissions to the permissions the configuration needs to report them with an error."
+	}
+	return diag.Diagnostics{
+		{
+			Severity: severity,
+			Summary:  "Missing Microsoft Graph permissions",
+			Detail:   detail,
+		},
+	}
+}
+
+func missingPermissions(granted []string, required []string) []string {
+	var missing []string
+	for _, r := range required {
+		found := false
+		for _, g := range granted {
+			if strings.EqualFold(r, g) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, r)
+		}
+	}
+	return missing
+}
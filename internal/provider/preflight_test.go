@@ -0,0 +1,48 @@
+package provider
+
+import (
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/client"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMissingPermissions(t *testing.T) {
+	granted := []string{"policy.readwrite.trustframework", "User.Read"}
+
+	missing := missingPermissions(granted, defaultRequiredPermissions)
+	if !reflect.DeepEqual(missing, []string{client.KeySetReadWritePermission}) {
+		t.Fatalf("unexpected missing permissions %v", missing)
+	}
+
+	diags := permissionDiagnostics(&client.MsGraphClientConfig{TenantID: "tenant", ClientID: "app"}, granted, defaultRequiredPermissions, true)
+	if !diags.HasError() {
+		t.Fatal("expected an error diagnostic")
+	}
+	if !strings.Contains(diags[0].Detail, client.KeySetReadWritePermission) || !strings.Contains(diags[0].Detail, "Client app") {
+		t.Fatalf("unexpected detail: %s", diags[0].Detail)
+	}
+
+	// The default permissions are only needed to write, so a read-only configuration is not failed.
+	if diags := permissionDiagnostics(&client.MsGraphClientConfig{}, granted, defaultRequiredPermissions, false); diags.HasError() || len(diags) != 1 {
+		t.Fatalf("expected a warning diagnostic, got %v", diags)
+	}
+
+	if diags := permissionDiagnostics(&client.MsGraphClientConfig{}, append(granted, client.KeySetReadWritePermission), defaultRequiredPermissions, true); diags != nil {
+		t.Fatalf("expected no diagnostics, got %v", diags)
+	}
+}
+
+func TestExpandPreflight(t *testing.T) {
+	if expandPreflight(nil) != nil {
+		t.Fatal("expected no preflight options without a preflight block")
+	}
+
+	options := expandPreflight([]interface{}{nil})
+	if !options.checkPermissions || !options.checkB2CTenant {
+		t.Fatal("expected all checks to be enabled for an empty preflight block")
+	}
+	if !reflect.DeepEqual(options.requiredPermissions, defaultRequiredPermissions) || options.permissionsSet {
+		t.Fatalf("unexpected required permissions %v", options.requiredPermissions)
+	}
+}
@@ -0,0 +1,83 @@
+package provider
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/client"
+	"time"
+)
+
+func policyPropagationSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Optional:    true,
+		MaxItems:    1,
+		Description: "How long to wait after a policy is uploaded until Microsoft Graph returns it",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"timeout": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					Default:      client.DefaultPolicyPropagationTimeout.String(),
+					ValidateFunc: validateDuration,
+					Description:  "How long to wait for an uploaded policy to be returned by Microsoft Graph.  Set to `0s` to not wait",
+				},
+				"min_interval": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					Default:      client.DefaultPolicyPropagationMinInterval.String(),
+					ValidateFunc: validatePositiveDuration,
+					Description:  "The time to wait before reading the policy again the first time, which doubles after every read.  It must be greater than zero",
+				},
+				"max_interval": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					Default:      client.DefaultPolicyPropagationMaxInterval.String(),
+					ValidateFunc: validatePositiveDuration,
+					Description:  "The longest time to wait between two reads of the policy.  It must not be less than `min_interval`",
+				},
+			},
+		},
+	}
+}
+
+// expandPolicyPropagation sets the policy propagation options of config, which keeps the defaults
+// without a policy_propagation block.
+func expandPolicyPropagation(raw []interface{}, config *client.MsGraphClientConfig) error {
+	config.PolicyPropagationTimeout = client.DefaultPolicyPropagationTimeout
+	config.PolicyPropagationMinInterval = client.DefaultPolicyPropagationMinInterval
+	config.PolicyPropagationMaxInterval = client.DefaultPolicyPropagationMaxInterval
+	if len(raw) == 0 {
+		return nil
+	}
+	// An empty block is decoded as nil.
+	if m, ok := raw[0].(map[string]interface{}); ok {
+		config.PolicyPropagationTimeout, _ = time.ParseDuration(m["timeout"].(string))
+		config.PolicyPropagationMinInterval, _ = time.ParseDuration(m["min_interval"].(string))
+		config.PolicyPropagationMaxInterval, _ = time.ParseDuration(m["max_interval"].(string))
+	}
+	if config.PolicyPropagationMaxInterval < config.PolicyPropagationMinInterval {
+		return fmt.Errorf("policy_propagation max_interval (%s) must not be less than min_interval (%s)",
+			config.PolicyPropagationMaxInterval, config.PolicyPropagationMinInterval)
+	}
+	return nil
+}
+
+func validateDuration(i interface{}, k string) (warnings []string, errors []error) {
+	d, err := time.ParseDuration(i.(string))
+	if err != nil {
+		errors = append(errors, fmt.Errorf("%s must be a duration such as 30s or 2m: %s", k, err))
+	} else if d < 0 {
+		errors = append(errors, fmt.Errorf("%s must not be negative", k))
+	}
+	return
+}
+
+// validatePositiveDuration validates an interval, which a zero value would turn into a busy loop.
+func validatePositiveDuration(i interface{}, k string) (warnings []string, errors []error) {
+	warnings, errors = validateDuration(i, k)
+	if d, err := time.ParseDuration(i.(string)); len(errors) == 0 && err == nil && d == 0 {
+		errors = append(errors, fmt.Errorf("%s must be greater than zero", k))
+	}
+	return
+}
@@ -0,0 +1,27 @@
+package provider
+
+import (
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/client"
+	"testing"
+)
+
+func TestPolicyPropagationIntervals(t *testing.T) {
+	if _, errs := validatePositiveDuration("0s", "min_interval"); len(errs) == 0 {
+		t.Fatal("expected a zero interval to be rejected")
+	}
+	if _, errs := validatePositiveDuration("500ms", "min_interval"); len(errs) != 0 {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+	if _, errs := validateDuration("0s", "timeout"); len(errs) != 0 {
+		t.Fatalf("expected a zero timeout to be allowed, got %v", errs)
+	}
+
+	var config client.MsGraphClientConfig
+	raw := []interface{}{map[string]interface{}{"timeout": "1m", "min_interval": "10s", "max_interval": "5s"}}
+	if err := expandPolicyPropagation(raw, &config); err == nil {
+		t.Fatal("expected max_interval less than min_interval to be rejected")
+	}
+	if err := expandPolicyPropagation(nil, &config); err != nil || config.PolicyPropagationMinInterval != client.DefaultPolicyPropagationMinInterval {
+		t.Fatalf("expected the defaults, got %s (%v)", config.PolicyPropagationMinInterval, err)
+	}
+}
@@ -2,11 +2,14 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/client"
-	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/datasources"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/graph"
 	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/resources"
+	"regexp"
 )
 
 func init() {
@@ -47,20 +50,157 @@ func New(version string) func() *schema.Provider {
 					DefaultFunc: schema.EnvDefaultFunc("ARM_CLIENT_SECRET", ""),
 					Description: "The application password to use when authenticating as a Service Principal using a Client Secret",
 				},
+				"client_certificate": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Sensitive:   true,
+					DefaultFunc: schema.EnvDefaultFunc("ARM_CLIENT_CERTIFICATE", ""),
+					Description: "Base64 encoded PKCS#12 or PEM certificate bundle to use when authenticating as a Service Principal using a Client Certificate",
+				},
+				"client_certificate_path": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					DefaultFunc: schema.EnvDefaultFunc("ARM_CLIENT_CERTIFICATE_PATH", ""),
+					Description: "The path to the PKCS#12 or PEM certificate bundle to use when authenticating as a Service Principal using a Client Certificate",
+				},
+				"client_certificate_password": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Sensitive:   true,
+					DefaultFunc: schema.EnvDefaultFunc("ARM_CLIENT_CERTIFICATE_PASSWORD", ""),
+					Description: "The password associated with the Client Certificate",
+				},
+				"use_oidc": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					DefaultFunc: schema.EnvDefaultFunc("ARM_USE_OIDC", false),
+					Description: "Allow OpenID Connect to be used for authentication",
+				},
+				"oidc_token": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Sensitive:   true,
+					DefaultFunc: schema.EnvDefaultFunc("ARM_OIDC_TOKEN", ""),
+					Description: "The ID token when authenticating using OpenID Connect (OIDC)",
+				},
+				"oidc_token_file_path": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					DefaultFunc: schema.EnvDefaultFunc("ARM_OIDC_TOKEN_FILE_PATH", ""),
+					Description: "The path to a file containing an ID token when authenticating using OpenID Connect (OIDC)",
+				},
+				"oidc_request_url": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					DefaultFunc: schema.MultiEnvDefaultFunc([]string{"ARM_OIDC_REQUEST_URL", "ACTIONS_ID_TOKEN_REQUEST_URL"}, ""),
+					Description: "The URL for the OIDC provider from which to request an ID token",
+				},
+				"oidc_request_token": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Sensitive:   true,
+					DefaultFunc: schema.MultiEnvDefaultFunc([]string{"ARM_OIDC_REQUEST_TOKEN", "ACTIONS_ID_TOKEN_REQUEST_TOKEN"}, ""),
+					Description: "The bearer token for the request to the OIDC provider",
+				},
+				"use_msi": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					DefaultFunc: schema.EnvDefaultFunc("ARM_USE_MSI", false),
+					Description: "Allow Managed Identity to be used for Authentication.  Set `client_id` to use a user-assigned identity",
+				},
+				"msi_endpoint": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					DefaultFunc: schema.EnvDefaultFunc("ARM_MSI_ENDPOINT", ""),
+					Description: "The path to a custom endpoint for Managed Identity - in most circumstances this should be detected automatically",
+				},
 				"use_cli": {
 					Type:        schema.TypeBool,
 					Optional:    true,
 					DefaultFunc: schema.EnvDefaultFunc("ARM_USE_CLI", true),
 					Description: "Allow Azure CLI to be used for Authentication",
 				},
+				"environment": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					DefaultFunc:  schema.EnvDefaultFunc("ARM_ENVIRONMENT", graph.DefaultEnvironment),
+					ValidateFunc: validation.StringInSlice(graph.EnvironmentNames(), true),
+					Description:  "The cloud environment which should be used. Possible values are `global`, `usgovernment` and `china`",
+				},
+				"graph_endpoint": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					DefaultFunc:  schema.EnvDefaultFunc("ARM_GRAPH_ENDPOINT", ""),
+					ValidateFunc: validation.IsURLWithHTTPorHTTPS,
+					Description:  "A custom Microsoft Graph endpoint, such as a mirror or a test server, to send all requests to instead of the environment's Graph endpoint",
+				},
+				"max_retries": {
+					Type:         schema.TypeInt,
+					Optional:     true,
+					Default:      3,
+					ValidateFunc: validation.IntAtLeast(0),
+					Description:  "The maximum number of times a failed or throttled Microsoft Graph request is retried",
+				},
+				"max_requests_per_second": {
+					Type:         schema.TypeFloat,
+					Optional:     true,
+					Default:      10,
+					ValidateFunc: validation.FloatAtLeast(0),
+					Description:  "The maximum number of Microsoft Graph requests per second sent to the tenant, shared by all resources.  Set to `0` to disable rate limiting",
+				},
+				"batch_requests": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					Default:     true,
+					Description: "Send concurrent policy and key set reads to Microsoft Graph in JSON batches of up to 20 requests",
+				},
+				"log_body_max_bytes": {
+					Type:         schema.TypeInt,
+					Optional:     true,
+					Default:      client.DefaultLogBodyMaxBytes,
+					ValidateFunc: validation.IntAtLeast(0),
+					Description:  "The maximum number of bytes of each Microsoft Graph request and response body included in debug logs.  Set to `0` to leave bodies out of the logs",
+				},
+				"partner_id": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					DefaultFunc:  schema.EnvDefaultFunc("ARM_PARTNER_ID", ""),
+					ValidateFunc: validation.Any(validation.IsUUID, validation.StringIsEmpty),
+					Description:  "A GUID used for Azure customer usage attribution, sent in the User-Agent of every Microsoft Graph request",
+				},
+				"read_only": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					Default:     false,
+					Description: "Only read from the tenant.  Plans that create, update, replace or destroy a resource fail, and the provider refuses to send any Microsoft Graph request other than a read, so that `terraform plan` can run safely with credentials that are allowed to write",
+				},
+				"policy_name_prefix": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					DefaultFunc:  schema.EnvDefaultFunc("AZUREADB2CIEF_POLICY_NAME_PREFIX", ""),
+					ValidateFunc: validation.Any(validation.StringIsEmpty, validation.StringMatch(regexp.MustCompile(`(?i)^B2C_1A_\w+$`), "must begin with B2C_1A_")),
+					Description:  "A prefix, such as `B2C_1A_alice_`, which replaces the `B2C_1A_` prefix of policy names in the tenant so that several developers can share a tenant.  The names of the policies, their `PolicyId`, `PublicPolicyUri` and `BasePolicy` are rewritten when they are uploaded and back when they are read",
+				},
+				"prefix_key_sets": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					Default:     false,
+					Description: "Also apply `policy_name_prefix` to the names of key sets and to the `StorageReferenceId` of the policies",
+				},
+				"adopt_existing": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					Default:     false,
+					Description: "Adopt the policies and key sets that already exist in the tenant when they are created, instead of failing with a conflict.  An adopted policy is replaced with the configured XML, and an adopted key set keeps its keys when the `use` and `kty` of its active key match.  Resources can override it with their own `adopt_existing`",
+				},
+				"preflight":          preflightSchema(),
+				"policy_propagation": policyPropagationSchema(),
+				"policy_history":     policyHistorySchema(),
 			},
 			ResourcesMap: map[string]*schema.Resource{
 				"azureadb2cief_trust_framework_policy":  resources.TrustFrameworkPolicyResource(),
 				"azureadb2cief_trust_framework_key_set": resources.TrustFrameworkKeySetResource(),
 			},
-			DataSourcesMap: map[string]*schema.Resource{
-				"azureadb2cief_client_config": datasources.ClientConfigDataSource(),
-			},
 		}
 
 		p.ConfigureContextFunc = configure(version, p)
@@ -73,19 +213,69 @@ func configure(version string, p *schema.Provider) func(context.Context, *schema
 	return func(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
 
 		authConfig := client.MsGraphClientConfig{
-			TenantID:            d.Get("tenant_id").(string),
-			ClientID:            d.Get("client_id").(string),
-			ClientSecret:        d.Get("client_secret").(string),
-			EnableAzureCliToken: d.Get("use_cli").(bool),
+			TenantID:                  d.Get("tenant_id").(string),
+			ClientID:                  d.Get("client_id").(string),
+			ClientSecret:              d.Get("client_secret").(string),
+			ClientCertificate:         d.Get("client_certificate").(string),
+			ClientCertificatePath:     d.Get("client_certificate_path").(string),
+			ClientCertificatePassword: d.Get("client_certificate_password").(string),
+			EnableAzureCliToken:       d.Get("use_cli").(bool),
+			EnableOidc:                d.Get("use_oidc").(bool),
+			OidcToken:                 d.Get("oidc_token").(string),
+			OidcTokenFilePath:         d.Get("oidc_token_file_path").(string),
+			OidcRequestUrl:            d.Get("oidc_request_url").(string),
+			OidcRequestToken:          d.Get("oidc_request_token").(string),
+			EnableMsi:                 d.Get("use_msi").(bool),
+			MsiEndpoint:               d.Get("msi_endpoint").(string),
+			Environment:               d.Get("environment").(string),
+			GraphEndpoint:             d.Get("graph_endpoint").(string),
+			MaxRetries:                d.Get("max_retries").(int),
+			MaxRequestsPerSecond:      d.Get("max_requests_per_second").(float64),
+			LogBodyMaxBytes:           d.Get("log_body_max_bytes").(int),
+			BatchRequests:             d.Get("batch_requests").(bool),
+			ProviderVersion:           version,
+			TerraformVersion:          p.TerraformVersion,
+			PartnerId:                 d.Get("partner_id").(string),
+			ReadOnly:                  d.Get("read_only").(bool),
+			PolicyNamePrefix:          d.Get("policy_name_prefix").(string),
+			PrefixKeySets:             d.Get("prefix_key_sets").(bool),
+			AdoptExisting:             d.Get("adopt_existing").(bool),
+		}
+
+		if err := expandPolicyPropagation(d.Get("policy_propagation").([]interface{}), &authConfig); err != nil {
+			return nil, diag.FromErr(err)
+		}
+		expandPolicyHistory(d.Get("policy_history").([]interface{}), &authConfig)
+
+		apiClient, diags := buildClient(authConfig)
+		if diags.HasError() {
+			return nil, diags
 		}
 
-		return buildClient(authConfig)
+		if options := expandPreflight(d.Get("preflight").([]interface{})); options != nil {
+			diags = append(diags, runPreflight(ctx, apiClient, options)...)
+			if diags.HasError() {
+				return nil, diags
+			}
+		}
+
+		return apiClient, diags
 	}
 }
 
 func buildClient(config client.MsGraphClientConfig) (*client.Client, diag.Diagnostics) {
 	apiClient, err := client.New(config)
 	if err != nil {
+		var authErr *client.AuthError
+		if errors.As(err, &authErr) {
+			return nil, diag.Diagnostics{
+				{
+					Severity: diag.Error,
+					Summary:  authErr.Summary(),
+					Detail:   authErr.Detail(),
+				},
+			}
+		}
 		return nil, diag.FromErr(err)
 	}
 
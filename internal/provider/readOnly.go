@@ -0,0 +1,78 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/client"
+)
+
+// readOnlyServer fails the plan of any change to a resource when the provider is read-only, so that
+// nothing is attempted at apply time.  It wraps the mux server, which covers the resources of both
+// the SDK and the framework providers, and the client refuses writes that get past it.
+type readOnlyServer struct {
+	tfprotov5.ProviderServer
+	sdkProvider *schema.Provider
+}
+
+func (s readOnlyServer) readOnly() bool {
+	apiClient, ok := s.sdkProvider.Meta().(*client.Client)
+	return ok && apiClient.ReadOnly()
+}
+
+func (s readOnlyServer) PlanResourceChange(ctx context.Context, req *tfprotov5.PlanResourceChangeRequest) (*tfprotov5.PlanResourceChangeResponse, error) {
+	resp, err := s.ProviderServer.PlanResourceChange(ctx, req)
+	if err != nil || !s.readOnly() || resp.PlannedState == nil {
+		return resp, err
+	}
+
+	action, err := s.plannedAction(ctx, req, resp)
+	if err != nil {
+		return nil, err
+	}
+	if action != "" {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
+			Severity: tfprotov5.DiagnosticSeverityError,
+			Summary:  fmt.Sprintf("Cannot %s %s in read-only mode", action, req.TypeName),
+			Detail: "The provider is configured with read_only = true, which does not allow any change to the tenant. " +
+				"Remove the change from the configuration, or plan it with a provider configuration that is not read-only.",
+		})
+	}
+	return resp, nil
+}
+
+// plannedAction returns the change a plan makes to a resource, or an empty string when it makes none.
+func (s readOnlyServer) plannedAction(ctx context.Context, req *tfprotov5.PlanResourceChangeRequest, resp *tfprotov5.PlanResourceChangeResponse) (string, error) {
+	schemas, err := s.ProviderServer.GetProviderSchema(ctx, &tfprotov5.GetProviderSchemaRequest{})
+	if err != nil {
+		return "", err
+	}
+	resourceSchema, ok := schemas.ResourceSchemas[req.TypeName]
+	if !ok {
+		return "", fmt.Errorf("unknown resource type %s", req.TypeName)
+	}
+
+	prior, err := req.PriorState.Unmarshal(resourceSchema.ValueType())
+	if err != nil {
+		return "", err
+	}
+	planned, err := resp.PlannedState.Unmarshal(resourceSchema.ValueType())
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case prior.IsNull() && planned.IsNull():
+		return "", nil
+	case prior.IsNull():
+		return "create", nil
+	case planned.IsNull():
+		return "destroy", nil
+	case len(resp.RequiresReplace) > 0:
+		return "replace", nil
+	case !prior.Equal(planned):
+		return "update", nil
+	}
+	return "", nil
+}
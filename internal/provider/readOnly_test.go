@@ -0,0 +1,111 @@
+package provider
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"strings"
+	"testing"
+)
+
+func TestReadOnlyPlans(t *testing.T) {
+	const typeName = "azureadb2cief_trust_framework_policy"
+	ctx := context.Background()
+
+	policy := func(xml string) map[string]tftypes.Value {
+		return map[string]tftypes.Value{
+			"id":     tftypes.NewValue(tftypes.String, "B2C_1A_Base"),
+			"name":   tftypes.NewValue(tftypes.String, "B2C_1A_Base"),
+			"policy": tftypes.NewValue(tftypes.String, xml),
+		}
+	}
+	current := policy(`<TrustFrameworkPolicy PolicyId="B2C_1A_Base"></TrustFrameworkPolicy>`)
+	changed := policy(`<TrustFrameworkPolicy PolicyId="B2C_1A_Base"><BuildingBlocks/></TrustFrameworkPolicy>`)
+	config := func(attributes map[string]tftypes.Value) map[string]tftypes.Value {
+		return map[string]tftypes.Value{"name": attributes["name"], "policy": attributes["policy"]}
+	}
+
+	cases := map[string]struct {
+		readOnly bool
+		prior    map[string]tftypes.Value
+		config   map[string]tftypes.Value
+		expected string
+	}{
+		"create":               {readOnly: true, config: config(current), expected: "Cannot create"},
+		"update":               {readOnly: true, prior: current, config: config(changed), expected: "Cannot update"},
+		"destroy":              {readOnly: true, prior: current, expected: "Cannot destroy"},
+		"no change":            {readOnly: true, prior: current, config: config(current)},
+		"create not read-only": {config: config(current)},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			server, err := NewMuxServer(ctx, "dev", New("dev")())
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := server()
+
+			schemas, err := s.GetProviderSchema(ctx, &tfprotov5.GetProviderSchemaRequest{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			configured, err := s.ConfigureProvider(ctx, &tfprotov5.ConfigureProviderRequest{
+				Config: objectValue(t, schemas.Provider.ValueType(), map[string]tftypes.Value{
+					"tenant_id":     tftypes.NewValue(tftypes.String, "contoso.onmicrosoft.com"),
+					"client_id":     tftypes.NewValue(tftypes.String, "00000000-0000-0000-0000-000000000001"),
+					"client_secret": tftypes.NewValue(tftypes.String, "Xk9pQ2vL7tRz4mWb"),
+					"read_only":     tftypes.NewValue(tftypes.Bool, c.readOnly),
+				}),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diags := errorDiagnostics(configured.Diagnostics); len(diags) > 0 {
+				t.Fatalf("unexpected diagnostics %s: %s", diags[0].Summary, diags[0].Detail)
+			}
+
+			resourceType := schemas.ResourceSchemas[typeName].ValueType()
+			nullState, err := tfprotov5.NewDynamicValue(resourceType, tftypes.NewValue(resourceType, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			prior, proposed, config := &nullState, &nullState, &nullState
+			if c.prior != nil {
+				prior = objectValue(t, resourceType, c.prior)
+			}
+			if c.config != nil {
+				config = objectValue(t, resourceType, c.config)
+				proposed = config
+				if c.prior != nil {
+					proposed = objectValue(t, resourceType, map[string]tftypes.Value{
+						"id":     c.prior["id"],
+						"name":   c.config["name"],
+						"policy": c.config["policy"],
+					})
+				}
+			}
+
+			planned, err := s.PlanResourceChange(ctx, &tfprotov5.PlanResourceChangeRequest{
+				TypeName:         typeName,
+				PriorState:       prior,
+				ProposedNewState: proposed,
+				Config:           config,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			diags := errorDiagnostics(planned.Diagnostics)
+			if c.expected == "" {
+				if len(diags) > 0 {
+					t.Fatalf("unexpected diagnostics %s: %s", diags[0].Summary, diags[0].Detail)
+				}
+				return
+			}
+			if len(diags) != 1 || !strings.Contains(diags[0].Summary, c.expected) {
+				t.Fatalf("expected an error %q, got %v", c.expected, diags)
+			}
+		})
+	}
+}
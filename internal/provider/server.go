@@ -0,0 +1,30 @@
+package provider
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-mux/tf5muxserver"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// NewMuxServer serves the SDK provider and the plugin framework provider as a single provider, so
+// that data sources and resources can move to the plugin framework one at a time without changing
+// their type names or state.  The SDK provider is configured first, and the framework provider
+// uses the client it configures.  Changes to resources are refused at plan time when the provider
+// is read-only, and skipped tenant checks are reported.
+func NewMuxServer(ctx context.Context, version string, sdkProvider *schema.Provider) (func() tfprotov5.ProviderServer, error) {
+	servers := []func() tfprotov5.ProviderServer{
+		sdkProvider.GRPCProvider,
+		providerserver.NewProtocol5(newFrameworkProvider(version, sdkProvider)),
+	}
+
+	muxServer, err := tf5muxserver.NewMuxServer(ctx, servers...)
+	if err != nil {
+		return nil, err
+	}
+	return func() tfprotov5.ProviderServer {
+		server := tenantDomainsServer{ProviderServer: muxServer.ProviderServer(), sdkProvider: sdkProvider}
+		return readOnlyServer{ProviderServer: server, sdkProvider: sdkProvider}
+	}, nil
+}
@@ -0,0 +1,124 @@
+package provider
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"testing"
+)
+
+// objectValue returns a value of an object type with the given attributes and every other attribute
+// null, or an empty list for blocks.
+func objectValue(t *testing.T, typ tftypes.Type, attributes map[string]tftypes.Value) *tfprotov5.DynamicValue {
+	objectType := typ.(tftypes.Object)
+	values := map[string]tftypes.Value{}
+	for name, attributeType := range objectType.AttributeTypes {
+		if value, ok := attributes[name]; ok {
+			values[name] = value
+		} else if attributeType.Is(tftypes.List{}) {
+			values[name] = tftypes.NewValue(attributeType, []tftypes.Value{})
+		} else {
+			values[name] = tftypes.NewValue(attributeType, nil)
+		}
+	}
+
+	value, err := tfprotov5.NewDynamicValue(objectType, tftypes.NewValue(objectType, values))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &value
+}
+
+func errorDiagnostics(diags []*tfprotov5.Diagnostic) []*tfprotov5.Diagnostic {
+	var errors []*tfprotov5.Diagnostic
+	for _, d := range diags {
+		if d.Severity == tfprotov5.DiagnosticSeverityError {
+			errors = append(errors, d)
+		}
+	}
+	return errors
+}
+
+func TestMuxServerSchema(t *testing.T) {
+	ctx := context.Background()
+	server, err := NewMuxServer(ctx, "dev", New("dev")())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := server().GetProviderSchema(ctx, &tfprotov5.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diags := errorDiagnostics(resp.Diagnostics); len(diags) > 0 {
+		t.Fatalf("expected the provider schemas of the SDK and framework providers to match, got %s: %s", diags[0].Summary, diags[0].Detail)
+	}
+
+	for _, name := range []string{"azureadb2cief_trust_framework_policy", "azureadb2cief_trust_framework_key_set"} {
+		if resp.ResourceSchemas[name] == nil {
+			t.Errorf("expected the %s resource", name)
+		}
+	}
+	if resp.DataSourceSchemas["azureadb2cief_client_config"] == nil {
+		t.Error("expected the azureadb2cief_client_config data source")
+	}
+	for _, name := range []string{"canonical_xml", "policy_id", "base_policy_id", "referenced_key_sets"} {
+		if resp.Functions[name] == nil {
+			t.Errorf("expected the %s function", name)
+		}
+	}
+}
+
+func TestMuxServerClientConfig(t *testing.T) {
+	ctx := context.Background()
+	server, err := NewMuxServer(ctx, "dev", New("dev")())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := server()
+
+	schemas, err := s.GetProviderSchema(ctx, &tfprotov5.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A client secret credential is created without contacting the tenant.
+	configured, err := s.ConfigureProvider(ctx, &tfprotov5.ConfigureProviderRequest{
+		Config: objectValue(t, schemas.Provider.ValueType(), map[string]tftypes.Value{
+			"tenant_id":     tftypes.NewValue(tftypes.String, "contoso.onmicrosoft.com"),
+			"client_id":     tftypes.NewValue(tftypes.String, "00000000-0000-0000-0000-000000000001"),
+			"client_secret": tftypes.NewValue(tftypes.String, "Xk9pQ2vL7tRz4mWb"),
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diags := errorDiagnostics(configured.Diagnostics); len(diags) > 0 {
+		t.Fatalf("unexpected diagnostics %s: %s", diags[0].Summary, diags[0].Detail)
+	}
+
+	dataSourceType := schemas.DataSourceSchemas["azureadb2cief_client_config"].ValueType()
+	read, err := s.ReadDataSource(ctx, &tfprotov5.ReadDataSourceRequest{
+		TypeName: "azureadb2cief_client_config",
+		Config:   objectValue(t, dataSourceType, nil),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diags := errorDiagnostics(read.Diagnostics); len(diags) > 0 {
+		t.Fatalf("unexpected diagnostics %s: %s", diags[0].Summary, diags[0].Detail)
+	}
+
+	state, err := read.State.Unmarshal(dataSourceType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var attributes map[string]tftypes.Value
+	var tenantId string
+	if err := state.As(&attributes); err != nil {
+		t.Fatal(err)
+	}
+	if err := attributes["tenant_id"].As(&tenantId); err != nil || tenantId != "contoso.onmicrosoft.com" {
+		t.Fatalf("expected the configured tenant, got %q (%v)", tenantId, err)
+	}
+}
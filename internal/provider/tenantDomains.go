@@ -0,0 +1,37 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/client"
+)
+
+// tenantDomainsServer warns once when the domains of the tenant could not be read while planning, as
+// the checks that policies name the configured tenant are then skipped.  The SDK's CustomizeDiff,
+// which runs the checks, can only fail a plan.
+type tenantDomainsServer struct {
+	tfprotov5.ProviderServer
+	sdkProvider *schema.Provider
+}
+
+func (s tenantDomainsServer) PlanResourceChange(ctx context.Context, req *tfprotov5.PlanResourceChangeRequest) (*tfprotov5.PlanResourceChangeResponse, error) {
+	resp, err := s.ProviderServer.PlanResourceChange(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	apiClient, ok := s.sdkProvider.Meta().(*client.Client)
+	if !ok {
+		return resp, nil
+	}
+	if domainsErr := apiClient.UnreportedTenantDomainsError(); domainsErr != nil {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
+			Severity: tfprotov5.DiagnosticSeverityWarning,
+			Summary:  "Skipped checking that policies are in the configured tenant",
+			Detail: fmt.Sprintf("The TenantId, PublicPolicyUri and BasePolicy of policies are not checked against the domains of the tenant: %s.  "+
+				"Grant the Organization.Read.All permission, or set tenant_id to a domain name of the tenant, such as contoso.onmicrosoft.com.", domainsErr),
+		})
+	}
+	return resp, nil
+}
@@ -0,0 +1,86 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/client"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/pkg/b2c"
+	"log"
+	"strings"
+)
+
+// adoptExistingSchema is the adopt_existing argument of a resource, which overrides the provider's.
+func adoptExistingSchema(object objectType) *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeBool,
+		Optional: true,
+		Description: fmt.Sprintf("Adopt a %s that already exists in the tenant when it is created, instead of failing.  "+
+			"Defaults to the provider's `adopt_existing`", object.description),
+	}
+}
+
+// adoptExisting reports whether an object that already exists is adopted, as set on the resource or
+// else on the provider.
+func adoptExisting(data *schema.ResourceData, meta interface{}) bool {
+	if config := data.GetRawConfig(); !config.IsNull() && config.IsKnown() {
+		if value := config.GetAttr("adopt_existing"); !value.IsNull() && value.IsKnown() {
+			return value.True()
+		}
+	}
+	apiClient := meta.(*client.Client)
+	return apiClient.Config != nil && apiClient.Config.AdoptExisting
+}
+
+// adoptedWarning reports that a resource adopted an object instead of creating it.
+func adoptedWarning(object objectType, name string, detail string) diag.Diagnostic {
+	log.Printf("[WARN] %s %q already exists and was adopted", object.description, name)
+	return diag.Diagnostic{
+		Severity: diag.Warning,
+		Summary:  fmt.Sprintf("Adopted existing %s %s", object.description, name),
+		Detail: fmt.Sprintf("%s %q already existed in the tenant and is now managed by this %s resource instead of being created.  %s",
+			object.description, name, object.resourceType, detail),
+	}
+}
+
+// adoptKeySet returns a key set that already exists, after checking that its active key, if it has
+// one, has the use and kty of the configuration.  The warning says whether a secret is uploaded to it.
+func adoptKeySet(ctx context.Context, keySetClient b2c.KeySetClient, name string, use string, kty string, uploadsSecret bool) (*b2c.TrustFrameworkKeySet, diag.Diagnostics) {
+	keyset, err := keySetClient.GetKeySet(ctx, name)
+	if err != nil {
+		return nil, errorDiagnostics(fmt.Sprintf("Could not read the existing Trust Framework Key Set %s", name), err)
+	}
+	if len(keyset.Keys) == 0 {
+		detail := "It had no keys, so a key was generated."
+		if uploadsSecret {
+			detail = "It had no keys, so the configured secret was uploaded."
+		}
+		return keyset, diag.Diagnostics{adoptedWarning(keySetObject, name, detail)}
+	}
+
+	key, err := keySetClient.GetActiveKey(ctx, name)
+	if err != nil {
+		return nil, errorDiagnostics(fmt.Sprintf("Could not read the active key of the existing Trust Framework Key Set %s", name), err)
+	}
+	if key.Use == nil || key.Kty == nil || !strings.EqualFold(*key.Use, use) || !strings.EqualFold(*key.Kty, kty) {
+		return nil, diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  fmt.Sprintf("Cannot adopt the existing Trust Framework Key Set %s", name),
+			Detail: fmt.Sprintf("The active key of the key set has use %q and kty %q, but the configuration has use %q and kty %q.  "+
+				"Change the configuration to match, or delete the key set.", stringValue(key.Use), stringValue(key.Kty), use, kty),
+		}}
+	}
+	detail := "Its keys were kept."
+	if uploadsSecret {
+		detail = "The configured secret was uploaded as its new active key, and its previous keys were kept."
+	}
+	return keyset, diag.Diagnostics{adoptedWarning(keySetObject, name, detail)}
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,663 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/client"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/pkg/b2c"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testPolicy = `<TrustFrameworkPolicy PolicyId="B2C_1A_Test" TenantId="contoso.onmicrosoft.com" />`
+
+func TestPolicyCreate(t *testing.T) {
+	tenant := newFakeTenant()
+	data := schema.TestResourceDataRaw(t, TrustFrameworkPolicyResource().Schema, map[string]interface{}{
+		"name":   "B2C_1A_Test",
+		"policy": testPolicy,
+	})
+
+	if diags := policyResourceCreate(context.Background(), data, tenant.meta()); diags.HasError() {
+		t.Fatalf("unexpected diagnostics %v", diags)
+	}
+	if data.Id() != "B2C_1A_Test" || tenant.policies["B2C_1A_Test"] != testPolicy {
+		t.Fatalf("expected the policy to be created, got id %q and %v", data.Id(), tenant.policies)
+	}
+	if !reflect.DeepEqual(tenant.calls, []string{"Create", "Get"}) {
+		t.Fatalf("expected the policy to be read after it was created, got %v", tenant.calls)
+	}
+}
+
+func TestPolicyCreateError(t *testing.T) {
+	tenant := newFakeTenant()
+	tenant.errors["Create"] = &b2c.GraphError{StatusCode: http.StatusBadRequest, Code: "AADB2C", Message: "Policy has an error", RequestId: "request"}
+	data := schema.TestResourceDataRaw(t, TrustFrameworkPolicyResource().Schema, map[string]interface{}{
+		"name":   "B2C_1A_Test",
+		"policy": testPolicy,
+	})
+
+	diags := policyResourceCreate(context.Background(), data, tenant.meta())
+	if !diags.HasError() || diags[0].Summary != "Could not create Trust Framework Policy B2C_1A_Test" {
+		t.Fatalf("expected the create to fail, got %v", diags)
+	}
+	if !strings.Contains(diags[0].Detail, "Policy has an error") || data.Id() != "" {
+		t.Fatalf("expected the Graph error in the detail and no id, got %q and id %q", diags[0].Detail, data.Id())
+	}
+}
+
+func TestPolicyReadRemovesMissingPolicy(t *testing.T) {
+	tenant := newFakeTenant()
+	data := TrustFrameworkPolicyResource().TestResourceData()
+	data.SetId("B2C_1A_Missing")
+
+	if diags := policyResourceRead(context.Background(), data, tenant.meta()); diags.HasError() {
+		t.Fatalf("unexpected diagnostics %v", diags)
+	}
+	if data.Id() != "" {
+		t.Fatal("expected the missing policy to be removed from state")
+	}
+}
+
+func TestPolicyReadError(t *testing.T) {
+	tenant := newFakeTenant()
+	tenant.errors["Get"] = &b2c.GraphError{StatusCode: http.StatusForbidden, Code: "Authorization_RequestDenied"}
+	data := TrustFrameworkPolicyResource().TestResourceData()
+	data.SetId("B2C_1A_Test")
+
+	diags := policyResourceRead(context.Background(), data, tenant.meta())
+	if !diags.HasError() || data.Id() != "B2C_1A_Test" {
+		t.Fatalf("expected the read to fail and keep the policy in state, got %v", diags)
+	}
+}
+
+func TestPolicyDelete(t *testing.T) {
+	tenant := newFakeTenant()
+	tenant.policies["B2C_1A_Test"] = testPolicy
+	data := TrustFrameworkPolicyResource().TestResourceData()
+	data.SetId("B2C_1A_Test")
+
+	if diags := policyResourceDelete(context.Background(), data, tenant.meta()); diags.HasError() {
+		t.Fatalf("unexpected diagnostics %v", diags)
+	}
+	if len(tenant.policies) != 0 {
+		t.Fatalf("expected the policy to be deleted, got %v", tenant.policies)
+	}
+}
+
+func TestPolicyDeleteWithDependents(t *testing.T) {
+	newTenant := func() *fakeTenant {
+		tenant := newFakeTenant()
+		tenant.policies["B2C_1A_TrustFrameworkBase"] = policyXml("B2C_1A_TrustFrameworkBase", "contoso.onmicrosoft.com", "contoso.onmicrosoft.com")
+		tenant.policies["B2C_1A_SignUp"] = policyXml("B2C_1A_SignUp", "contoso.onmicrosoft.com", "contoso.onmicrosoft.com")
+		tenant.policies["B2C_1A_Test"] = testPolicy
+		return tenant
+	}
+	wait := dependentPoliciesWait{timeout: time.Second, minInterval: time.Millisecond, maxInterval: 10 * time.Millisecond}
+
+	t.Run("dependent", func(t *testing.T) {
+		tenant := newTenant()
+		meta := tenant.meta()
+		diags := waitForDependentPolicies(context.Background(), meta, "B2C_1A_TrustFrameworkBase", wait)
+		if !diags.HasError() || !strings.Contains(diags[0].Detail, "B2C_1A_SignUp") || strings.Contains(diags[0].Detail, "B2C_1A_Test") {
+			t.Fatalf("expected the delete to fail naming the dependent policy, got %v", diags)
+		}
+
+		// The base policy of every policy is only read once per run.
+		reads := 0
+		for _, call := range tenant.calls {
+			if call == "Get" {
+				reads++
+			}
+		}
+		if reads != 2 {
+			t.Fatalf("expected each policy to be read once, got %d reads", reads)
+		}
+	})
+
+	t.Run("dependent deleted", func(t *testing.T) {
+		tenant := newTenant()
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			tenant.Delete(context.Background(), "B2C_1A_SignUp")
+		}()
+		if diags := waitForDependentPolicies(context.Background(), tenant.meta(), "B2C_1A_TrustFrameworkBase", wait); diags.HasError() {
+			t.Fatalf("expected the delete to wait for the dependent policy, got %v", diags)
+		}
+	})
+
+	t.Run("zero interval", func(t *testing.T) {
+		tenant := newTenant()
+		diags := waitForDependentPolicies(context.Background(), tenant.meta(), "B2C_1A_TrustFrameworkBase", dependentPoliciesWait{timeout: 50 * time.Millisecond})
+		if !diags.HasError() {
+			t.Fatal("expected the delete to fail")
+		}
+		lists := 0
+		for _, call := range tenant.calls {
+			if call == "List" {
+				lists++
+			}
+		}
+		if lists > 10 {
+			t.Fatalf("expected the interval to be floored, got %d lists", lists)
+		}
+	})
+
+	t.Run("force_delete", func(t *testing.T) {
+		tenant := newTenant()
+		data := TrustFrameworkPolicyResource().TestResourceData()
+		data.SetId("B2C_1A_TrustFrameworkBase")
+		data.Set("force_delete", true)
+		if diags := policyResourceDelete(context.Background(), data, tenant.meta()); diags.HasError() {
+			t.Fatalf("unexpected diagnostics %v", diags)
+		}
+		if _, exists := tenant.policies["B2C_1A_TrustFrameworkBase"]; exists || slices.Contains(tenant.calls, "List") {
+			t.Fatalf("expected the policy to be deleted without looking for dependents, got %v", tenant.calls)
+		}
+	})
+}
+
+func TestKeySetCreate(t *testing.T) {
+	cases := map[string]struct {
+		config map[string]interface{}
+		method string
+	}{
+		"generated": {
+			config: map[string]interface{}{"name": "B2C_1A_Signing", "use": "sig", "kty": "RSA", "exp": 1900000000},
+			method: "GenerateKey",
+		},
+		"secret": {
+			config: map[string]interface{}{"name": "B2C_1A_Signing", "use": "sig", "kty": "oct", "k": "Xk9pQ2vL7tRz4mWb"},
+			method: "UploadSecret",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			tenant := newFakeTenant()
+			data := schema.TestResourceDataRaw(t, TrustFrameworkKeySetResource().Schema, c.config)
+
+			if diags := createKey(context.Background(), data, tenant.meta()); diags.HasError() {
+				t.Fatalf("unexpected diagnostics %v", diags)
+			}
+			expected := []string{"CreateKey", c.method, "GetKeySet", "GetActiveKey"}
+			if !reflect.DeepEqual(tenant.calls, expected) {
+				t.Fatalf("expected calls %v, got %v", expected, tenant.calls)
+			}
+			if data.Id() != "B2C_1A_Signing" || data.Get("use") != "sig" || data.Get("kty") != c.config["kty"] {
+				t.Fatalf("unexpected state %v", data.State())
+			}
+			if exp, ok := c.config["exp"]; ok && data.Get("exp") != exp {
+				t.Fatalf("expected exp %v, got %v", exp, data.Get("exp"))
+			}
+		})
+	}
+}
+
+func TestKeySetReadWithoutKeys(t *testing.T) {
+	tenant := newFakeTenant()
+	tenant.keySets["B2C_1A_Empty"] = nil
+	data := TrustFrameworkKeySetResource().TestResourceData()
+	data.SetId("B2C_1A_Empty")
+
+	if diags := readKey(context.Background(), data, tenant.meta()); diags.HasError() {
+		t.Fatalf("unexpected diagnostics %v", diags)
+	}
+	if !reflect.DeepEqual(tenant.calls, []string{"GetKeySet"}) || data.Id() != "B2C_1A_Empty" {
+		t.Fatalf("expected the active key not to be read for an empty key set, got %v", tenant.calls)
+	}
+}
+
+func TestKeySetReadRemovesMissingKeySet(t *testing.T) {
+	tenant := newFakeTenant()
+	data := TrustFrameworkKeySetResource().TestResourceData()
+	data.SetId("B2C_1A_Missing")
+
+	if diags := readKey(context.Background(), data, tenant.meta()); diags.HasError() {
+		t.Fatalf("unexpected diagnostics %v", diags)
+	}
+	if data.Id() != "" {
+		t.Fatal("expected the missing key set to be removed from state")
+	}
+}
+
+func keySetConfig(p *protocolProvider, attributes map[string]tftypes.Value) tftypes.Value {
+	attributes["name"] = tftypes.NewValue(tftypes.String, "B2C_1A_ClientSecret")
+	attributes["use"] = tftypes.NewValue(tftypes.String, "sig")
+	attributes["kty"] = tftypes.NewValue(tftypes.String, "oct")
+	return p.config(keySetResourceType, attributes)
+}
+
+func TestKeySetWriteOnlySecret(t *testing.T) {
+	tenant := newFakeTenant()
+	p := newProtocolProvider(t, tenant)
+
+	state, diags := p.apply(keySetResourceType, p.null(keySetResourceType), keySetConfig(p, map[string]tftypes.Value{
+		"k_wo":         tftypes.NewValue(tftypes.String, "Xk9pQ2vL7tRz4mWb"),
+		"k_wo_version": tftypes.NewValue(tftypes.Number, 1),
+	}))
+	if hasError(diags) {
+		t.Fatalf("unexpected diagnostics %s", diagnosticsString(diags))
+	}
+
+	keys := tenant.keySets["B2C_1A_ClientSecret"]
+	if len(keys) != 1 || keys[0].K == nil || *keys[0].K != "Xk9pQ2vL7tRz4mWb" {
+		t.Fatalf("expected the write-only secret to be uploaded, got %+v", keys)
+	}
+	if !p.attribute(state, "k_wo").IsNull() || strings.Contains(state.String(), "Xk9pQ2vL7tRz4mWb") {
+		t.Fatalf("expected the secret not to be stored in the state, got %s", state)
+	}
+
+	// A new version uploads the new secret as the active key of the same key set.
+	tenant.calls = nil
+	state, diags = p.apply(keySetResourceType, state, keySetConfig(p, map[string]tftypes.Value{
+		"k_wo":         tftypes.NewValue(tftypes.String, "Rz4mWbXk9pQ2vL7t"),
+		"k_wo_version": tftypes.NewValue(tftypes.Number, 2),
+	}))
+	if hasError(diags) {
+		t.Fatalf("unexpected diagnostics %s", diagnosticsString(diags))
+	}
+	keys = tenant.keySets["B2C_1A_ClientSecret"]
+	if len(keys) != 2 || *keys[1].K != "Rz4mWbXk9pQ2vL7t" {
+		t.Fatalf("expected the new secret to be uploaded, got %+v", keys)
+	}
+	if !reflect.DeepEqual(tenant.calls, []string{"GetKeySet", "GetActiveKey", "UploadSecret", "GetKeySet", "GetActiveKey"}) && !reflect.DeepEqual(tenant.calls, []string{"UploadSecret", "GetKeySet", "GetActiveKey"}) {
+		t.Fatalf("expected the key set to be updated in place, got %v", tenant.calls)
+	}
+	if strings.Contains(state.String(), "Rz4mWbXk9pQ2vL7t") {
+		t.Fatalf("expected the secret not to be stored in the state, got %s", state)
+	}
+}
+
+func TestKeySetWriteOnlySecretValidation(t *testing.T) {
+	cases := map[string]struct {
+		attributes map[string]tftypes.Value
+		expected   string
+	}{
+		"both secrets": {
+			attributes: map[string]tftypes.Value{
+				"k":    tftypes.NewValue(tftypes.String, "Xk9pQ2vL7tRz4mWb"),
+				"k_wo": tftypes.NewValue(tftypes.String, "Xk9pQ2vL7tRz4mWb"),
+			},
+			expected: "conflicts with k",
+		},
+		"version without secret": {
+			attributes: map[string]tftypes.Value{
+				"k_wo_version": tftypes.NewValue(tftypes.Number, 1),
+			},
+			expected: "k_wo",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			p := newProtocolProvider(t, newFakeTenant())
+			_, diags := p.plan(keySetResourceType, p.null(keySetResourceType), keySetConfig(p, c.attributes))
+			if !hasError(diags) || !strings.Contains(diagnosticsString(diags), c.expected) {
+				t.Fatalf("expected an error mentioning %q, got %s", c.expected, diagnosticsString(diags))
+			}
+		})
+	}
+}
+
+func policyXml(policyId string, tenant string, baseTenant string) string {
+	return fmt.Sprintf(`<TrustFrameworkPolicy xmlns="http://schemas.microsoft.com/online/cpim/schemas/2013/06" TenantId="%[2]s" PolicyId="%[1]s" PublicPolicyUri="http://%[2]s/%[1]s">
+  <BasePolicy>
+    <TenantId>%[3]s</TenantId>
+    <PolicyId>B2C_1A_TrustFrameworkBase</PolicyId>
+  </BasePolicy>
+</TrustFrameworkPolicy>`, policyId, tenant, baseTenant)
+}
+
+func TestPolicyNameFromXml(t *testing.T) {
+	tenant := newFakeTenant()
+	p := newProtocolProvider(t, tenant)
+
+	state, diags := p.apply(policyResourceType, p.null(policyResourceType), p.config(policyResourceType, map[string]tftypes.Value{
+		"policy": tftypes.NewValue(tftypes.String, policyXml("B2C_1A_SignUp", "contoso.onmicrosoft.com", "contoso.onmicrosoft.com")),
+	}))
+	if hasError(diags) {
+		t.Fatalf("unexpected diagnostics %s", diagnosticsString(diags))
+	}
+	var name, id string
+	p.must(p.attribute(state, "name").As(&name))
+	p.must(p.attribute(state, "id").As(&id))
+	if name != "B2C_1A_SignUp" || id != "B2C_1A_SignUp" {
+		t.Fatalf("expected the name to be taken from the PolicyId, got name %q and id %q", name, id)
+	}
+	if _, ok := tenant.policies["B2C_1A_SignUp"]; !ok {
+		t.Fatal("expected the policy to be created")
+	}
+}
+
+func TestPolicyXmlConsistency(t *testing.T) {
+	cases := map[string]struct {
+		name     string
+		xml      string
+		expected string
+	}{
+		"matching name": {
+			name: "B2C_1A_signup",
+			xml:  policyXml("B2C_1A_SignUp", "contoso.onmicrosoft.com", "contoso.onmicrosoft.com"),
+		},
+		"mismatched name": {
+			name:     "B2C_1A_SignIn",
+			xml:      policyXml("B2C_1A_SignUp", "contoso.onmicrosoft.com", "contoso.onmicrosoft.com"),
+			expected: `does not match the name "B2C_1A_SignIn"`,
+		},
+		"PolicyId without B2C_1A_": {
+			xml:      policyXml("SignUp", "contoso.onmicrosoft.com", "contoso.onmicrosoft.com"),
+			expected: "must begin with B2C_1A_",
+		},
+		"other tenant": {
+			name:     "B2C_1A_SignUp",
+			xml:      policyXml("B2C_1A_SignUp", "fabrikam.onmicrosoft.com", "contoso.onmicrosoft.com"),
+			expected: `PublicPolicyUri "http://fabrikam.onmicrosoft.com/B2C_1A_SignUp"`,
+		},
+		"base policy in other tenant": {
+			name:     "B2C_1A_SignUp",
+			xml:      policyXml("B2C_1A_SignUp", "contoso.onmicrosoft.com", "fabrikam.onmicrosoft.com"),
+			expected: `BasePolicy of the policy XML is in another tenant, "fabrikam.onmicrosoft.com"`,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			tenant := newFakeTenant()
+			tenant.tenantId = "Contoso.onmicrosoft.com"
+			p := newProtocolProvider(t, tenant)
+
+			attributes := map[string]tftypes.Value{"policy": tftypes.NewValue(tftypes.String, c.xml)}
+			if c.name != "" {
+				attributes["name"] = tftypes.NewValue(tftypes.String, c.name)
+			}
+			_, diags := p.plan(policyResourceType, p.null(policyResourceType), p.config(policyResourceType, attributes))
+			if c.expected == "" {
+				if hasError(diags) {
+					t.Fatalf("unexpected diagnostics %s", diagnosticsString(diags))
+				}
+				return
+			}
+			if !hasError(diags) || !strings.Contains(diagnosticsString(diags), c.expected) {
+				t.Fatalf("expected an error mentioning %q, got %s", c.expected, diagnosticsString(diags))
+			}
+		})
+	}
+}
+
+func hasWarning(diags []*tfprotov5.Diagnostic, summary string) bool {
+	for _, d := range diags {
+		if d.Severity == tfprotov5.DiagnosticSeverityWarning && strings.Contains(d.Summary, summary) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPolicyAdoptExisting(t *testing.T) {
+	configured := policyXml("B2C_1A_SignUp", "contoso.onmicrosoft.com", "contoso.onmicrosoft.com")
+	for _, adopt := range []bool{false, true} {
+		t.Run(fmt.Sprintf("adopt_existing=%t", adopt), func(t *testing.T) {
+			tenant := newFakeTenant()
+			tenant.policies["B2C_1A_SignUp"] = "<TrustFrameworkPolicy PolicyId=\"B2C_1A_SignUp\" />"
+			p := newProtocolProvider(t, tenant)
+
+			_, diags := p.apply(policyResourceType, p.null(policyResourceType), p.config(policyResourceType, map[string]tftypes.Value{
+				"policy":         tftypes.NewValue(tftypes.String, configured),
+				"adopt_existing": tftypes.NewValue(tftypes.Bool, adopt),
+			}))
+			if !adopt {
+				if !hasError(diags) || !strings.Contains(diagnosticsString(diags), "Could not create Trust Framework Policy B2C_1A_SignUp") {
+					t.Fatalf("expected the conflict to fail the create, got %s", diagnosticsString(diags))
+				}
+				return
+			}
+			if hasError(diags) || !hasWarning(diags, "Adopted existing Trust Framework Policy B2C_1A_SignUp") {
+				t.Fatalf("expected the policy to be adopted with a warning, got %s", diagnosticsString(diags))
+			}
+			if tenant.policies["B2C_1A_SignUp"] != configured {
+				t.Fatalf("expected the adopted policy to be replaced, got %s", tenant.policies["B2C_1A_SignUp"])
+			}
+		})
+	}
+}
+
+func TestKeySetAdoptExisting(t *testing.T) {
+	cases := map[string]struct {
+		kty      string
+		secret   string
+		expected string
+		detail   string
+		keys     int
+	}{
+		"matching key":  {kty: "OCT", detail: "Its keys were kept.", keys: 1},
+		"secret":        {kty: "OCT", secret: "Xk9pQ2vL7tRz4mWb", detail: "The configured secret was uploaded as its new active key", keys: 2},
+		"different kty": {kty: "RSA", expected: `has use "sig" and kty "RSA"`},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			tenant := newFakeTenant()
+			use, kty := "sig", c.kty
+			tenant.keySets["B2C_1A_ClientSecret"] = []b2c.TrustFrameworkKey{{Use: &use, Kty: &kty}}
+			meta := tenant.meta()
+			meta.Config = &client.MsGraphClientConfig{AdoptExisting: true}
+			p := newProtocolProvider(t, tenant)
+			p.provider.SetMeta(meta)
+
+			attributes := map[string]tftypes.Value{}
+			if c.secret != "" {
+				attributes["k"] = tftypes.NewValue(tftypes.String, c.secret)
+			}
+			_, diags := p.apply(keySetResourceType, p.null(keySetResourceType), keySetConfig(p, attributes))
+			if c.expected != "" {
+				if !hasError(diags) || !strings.Contains(diagnosticsString(diags), c.expected) {
+					t.Fatalf("expected an error mentioning %q, got %s", c.expected, diagnosticsString(diags))
+				}
+				return
+			}
+			if hasError(diags) || !hasWarning(diags, "Adopted existing Trust Framework Key Set B2C_1A_ClientSecret") || !strings.Contains(diagnosticsString(diags), c.detail) {
+				t.Fatalf("expected the key set to be adopted with a warning, got %s", diagnosticsString(diags))
+			}
+			if len(tenant.keySets["B2C_1A_ClientSecret"]) != c.keys {
+				t.Fatalf("expected the adopted key set to keep its key, got %+v", tenant.keySets["B2C_1A_ClientSecret"])
+			}
+		})
+	}
+}
+
+func TestPolicyWaitsForPropagation(t *testing.T) {
+	cases := map[string]struct {
+		staleReads int
+		expected   string
+	}{
+		"propagated":     {staleReads: 3},
+		"not propagated": {staleReads: 1000, expected: "Timed out waiting for Trust Framework Policy B2C_1A_SignUp to propagate"},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			tenant := newFakeTenant()
+			meta := tenant.meta()
+			meta.Config = &client.MsGraphClientConfig{
+				PolicyPropagationTimeout:     50 * time.Millisecond,
+				PolicyPropagationMinInterval: time.Millisecond,
+				PolicyPropagationMaxInterval: 5 * time.Millisecond,
+			}
+			p := newProtocolProvider(t, tenant)
+			p.provider.SetMeta(meta)
+
+			original := policyXml("B2C_1A_SignUp", "contoso.onmicrosoft.com", "contoso.onmicrosoft.com")
+			state, diags := p.apply(policyResourceType, p.null(policyResourceType), p.config(policyResourceType, map[string]tftypes.Value{
+				"policy": tftypes.NewValue(tftypes.String, original),
+			}))
+			if hasError(diags) {
+				t.Fatalf("unexpected diagnostics %s", diagnosticsString(diags))
+			}
+
+			tenant.calls = nil
+			tenant.staleReads = c.staleReads
+			updated := strings.Replace(original, "<BasePolicy>", "<BuildingBlocks /><BasePolicy>", 1)
+			state, diags = p.apply(policyResourceType, state, p.config(policyResourceType, map[string]tftypes.Value{
+				"policy": tftypes.NewValue(tftypes.String, updated),
+			}))
+			if c.expected != "" {
+				if !hasError(diags) || !strings.Contains(diagnosticsString(diags), c.expected) {
+					t.Fatalf("expected %q, got %s", c.expected, diagnosticsString(diags))
+				}
+				return
+			}
+			if hasError(diags) {
+				t.Fatalf("unexpected diagnostics %s", diagnosticsString(diags))
+			}
+			var policy string
+			p.must(p.attribute(state, "policy").As(&policy))
+			if policy != updated || tenant.staleReads != 0 {
+				t.Fatalf("expected the updated policy in the state, got %s", policy)
+			}
+			// The first read captures the policy before it is overwritten.
+			if !reflect.DeepEqual(tenant.calls, []string{"Get", "Update", "Get", "Get", "Get"}) {
+				t.Fatalf("expected the policy to be read until it propagated, got %v", tenant.calls)
+			}
+		})
+	}
+}
+
+func TestWaitForPolicyWithZeroInterval(t *testing.T) {
+	tenant := newFakeTenant()
+	tenant.upload("B2C_1A_SignUp", testPolicy)
+	tenant.upload("B2C_1A_SignUp", strings.Replace(testPolicy, "/>", "><BuildingBlocks /></TrustFrameworkPolicy>", 1))
+	tenant.staleReads = 1000
+
+	options := propagationOptions{timeout: 100 * time.Millisecond}
+	_, diags := waitForPolicy(context.Background(), tenant, "B2C_1A_SignUp", tenant.policies["B2C_1A_SignUp"], options)
+	if !diags.HasError() {
+		t.Fatal("expected the wait to time out")
+	}
+	// The interval is floored, so the policy is not read in a busy loop until the deadline.
+	if len(tenant.calls) != 1 {
+		t.Fatalf("expected a single read before the deadline, got %d", len(tenant.calls))
+	}
+}
+
+func TestPolicyHistory(t *testing.T) {
+	history := &policyHistory{directory: t.TempDir(), maxVersions: 2}
+	replaced := time.Date(2026, 10, 18, 8, 15, 0, 0, time.UTC)
+
+	for i := 0; i < 3; i++ {
+		if _, err := history.record("B2C_1A_SignUp", fmt.Sprintf("<v%d />", i), replaced.Add(time.Duration(i)*time.Second)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	versions, err := history.versions("B2C_1A_SignUp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(versions, []string{"20261018T081501.000Z", "20261018T081502.000Z"}) {
+		t.Fatalf("expected the oldest version to be removed, got %v", versions)
+	}
+	if policyXml, err := history.read("B2C_1A_SignUp", versions[0]); err != nil || policyXml != "<v1 />" {
+		t.Fatalf("unexpected version %q (%v)", policyXml, err)
+	}
+	if _, err := history.read("B2C_1A_SignUp", "../B2C_1A_Other/20261018T081501.000Z"); err == nil {
+		t.Fatal("expected an error for a version outside the history of the policy")
+	}
+}
+
+func TestPolicyRollback(t *testing.T) {
+	tenant := newFakeTenant()
+	meta := tenant.meta()
+	meta.Config = &client.MsGraphClientConfig{PolicyHistoryDirectory: t.TempDir()}
+	p := newProtocolProvider(t, tenant)
+	p.provider.SetMeta(meta)
+
+	version := func(n int) string {
+		return strings.Replace(policyXml("B2C_1A_SignUp", "contoso.onmicrosoft.com", "contoso.onmicrosoft.com"),
+			"<BasePolicy>", fmt.Sprintf("<BuildingBlocks Version=\"%d\" /><BasePolicy>", n), 1)
+	}
+	apply := func(state tftypes.Value, policy string, rollbackTo string) tftypes.Value {
+		t.Helper()
+		attributes := map[string]tftypes.Value{"policy": tftypes.NewValue(tftypes.String, policy)}
+		if rollbackTo != "" {
+			attributes["rollback_to"] = tftypes.NewValue(tftypes.String, rollbackTo)
+		}
+		state, diags := p.apply(policyResourceType, state, p.config(policyResourceType, attributes))
+		if hasError(diags) {
+			t.Fatalf("unexpected diagnostics %s", diagnosticsString(diags))
+		}
+		return state
+	}
+
+	if _, diags := p.plan(policyResourceType, p.null(policyResourceType), p.config(policyResourceType, map[string]tftypes.Value{
+		"policy":      tftypes.NewValue(tftypes.String, version(1)),
+		"rollback_to": tftypes.NewValue(tftypes.String, "previous"),
+	})); !hasError(diags) || !strings.Contains(diagnosticsString(diags), "rollback_to can only be set on a policy that was already created") {
+		t.Fatalf("expected rollback_to to be rejected when the policy is created, got %s", diagnosticsString(diags))
+	}
+
+	state := apply(p.null(policyResourceType), version(1), "")
+	state = apply(state, version(2), "")
+	var previous string
+	p.must(p.attribute(state, "previous_policy").As(&previous))
+	if previous != version(1) {
+		t.Fatalf("expected the overwritten policy in previous_policy, got %s", previous)
+	}
+
+	// Rolling back keeps the configured policy out of the tenant until rollback_to is removed.
+	state = apply(state, version(2), "previous")
+	if tenant.policies["B2C_1A_SignUp"] != version(1) {
+		t.Fatalf("expected the previous policy to be uploaded, got %s", tenant.policies["B2C_1A_SignUp"])
+	}
+	tenant.calls = nil
+	state = apply(state, version(3), "previous")
+	for _, call := range tenant.calls {
+		if call == "Update" {
+			t.Fatalf("expected no change while the policy is rolled back, got %v", tenant.calls)
+		}
+	}
+	if tenant.policies["B2C_1A_SignUp"] != version(1) {
+		t.Fatalf("expected the rolled back policy to be kept, got %s", tenant.policies["B2C_1A_SignUp"])
+	}
+
+	history := policyHistoryOf(meta)
+	versions, err := history.versions("B2C_1A_SignUp")
+	if err != nil || len(versions) != 2 {
+		t.Fatalf("expected a version per overwrite, got %v (%v)", versions, err)
+	}
+	state = apply(state, version(3), versions[1])
+	if tenant.policies["B2C_1A_SignUp"] != version(2) {
+		t.Fatalf("expected the version of the history to be uploaded, got %s", tenant.policies["B2C_1A_SignUp"])
+	}
+
+	state = apply(state, version(3), "")
+	if tenant.policies["B2C_1A_SignUp"] != version(3) {
+		t.Fatalf("expected the configured policy to be uploaded after the rollback, got %s", tenant.policies["B2C_1A_SignUp"])
+	}
+
+	// A failed upload keeps previous_policy and the history as they were.
+	versions, _ = history.versions("B2C_1A_SignUp")
+	tenant.errors["Update"] = &b2c.GraphError{StatusCode: http.StatusBadRequest, Message: "invalid policy"}
+	failed, diags := p.apply(policyResourceType, state, p.config(policyResourceType, map[string]tftypes.Value{
+		"policy": tftypes.NewValue(tftypes.String, version(4)),
+	}))
+	if !hasError(diags) {
+		t.Fatal("expected the failed upload to be reported")
+	}
+	if !failed.IsNull() {
+		p.must(p.attribute(failed, "previous_policy").As(&previous))
+		if previous != version(2) {
+			t.Fatalf("expected previous_policy to be kept after a failed upload, got %s", previous)
+		}
+	}
+	if after, err := history.versions("B2C_1A_SignUp"); err != nil || len(after) != len(versions) {
+		t.Fatalf("expected no version to be recorded for a failed upload, got %v (%v)", after, err)
+	}
+}
@@ -0,0 +1,119 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/client"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/pkg/b2c"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dependentPoliciesConcurrency is the most policies read at once to find their base policy.
+const dependentPoliciesConcurrency = 10
+
+// dependentPoliciesWait is how long and how often a delete checks whether the policies inheriting from
+// the policy were deleted.
+type dependentPoliciesWait struct {
+	timeout     time.Duration
+	minInterval time.Duration
+	maxInterval time.Duration
+}
+
+// defaultDependentPoliciesWait leaves the policies deleted in the same run time to be deleted first.
+var defaultDependentPoliciesWait = dependentPoliciesWait{
+	timeout:     time.Minute,
+	minInterval: 2 * time.Second,
+	maxInterval: 10 * time.Second,
+}
+
+// dependentPoliciesMinInterval is the shortest time between two checks, so that they never run in a
+// busy loop.
+const dependentPoliciesMinInterval = 10 * time.Millisecond
+
+// dependentPolicies returns the sorted names of the policies of the tenant whose BasePolicy is the
+// policy name.  The base policy of every policy is read once per run, with a few reads at a time, so
+// that deleting many policies does not read every policy for each of them.
+func dependentPolicies(ctx context.Context, apiClient *client.Client, name string) ([]string, error) {
+	policies, err := apiClient.TrustFrameworkPolicyClient.List().Collect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		wg         sync.WaitGroup
+		mu         sync.Mutex
+		dependents []string
+		errs       []error
+	)
+	semaphore := make(chan struct{}, dependentPoliciesConcurrency)
+	for _, policy := range policies {
+		if strings.EqualFold(policy.Name, name) {
+			continue
+		}
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(policyName string) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			basePolicy, err := apiClient.BasePolicy(ctx, policyName)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err != nil && !b2c.IsNotFound(err):
+				errs = append(errs, err)
+			case err == nil && strings.EqualFold(basePolicy, name):
+				dependents = append(dependents, policyName)
+			}
+		}(policy.Name)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+	slices.Sort(dependents)
+	return dependents, nil
+}
+
+// waitForDependentPolicies fails the delete of a policy that other policies of the tenant inherit
+// from.  Terraform deletes resources without dependencies between them concurrently, so it first waits
+// for the dependents to be deleted.
+func waitForDependentPolicies(ctx context.Context, apiClient *client.Client, name string, wait dependentPoliciesWait) diag.Diagnostics {
+	interval := max(wait.minInterval, dependentPoliciesMinInterval)
+	maxInterval := max(wait.maxInterval, interval)
+	deadline := time.Now().Add(wait.timeout)
+	for {
+		dependents, err := dependentPolicies(ctx, apiClient, name)
+		if err != nil {
+			return errorDiagnostics(fmt.Sprintf("Could not find the policies inheriting from Trust Framework Policy %s", name), err)
+		}
+		if len(dependents) == 0 {
+			return nil
+		}
+		if time.Now().Add(interval).After(deadline) {
+			return diag.Diagnostics{{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("Trust Framework Policy %s is the base policy of other policies", name),
+				Detail: fmt.Sprintf("Deleting it would break the policies inheriting from it: %s.  "+
+					"Delete them first, the policies managed in the same configuration are deleted first when they depend on this resource, "+
+					"or set force_delete to delete it anyway.", strings.Join(dependents, ", ")),
+			}}
+		}
+
+		log.Printf("[DEBUG] Trust Framework Policy %q is the base policy of %s, checking again in %s", name, strings.Join(dependents, ", "), interval)
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return diag.FromErr(ctx.Err())
+		case <-timer.C:
+		}
+
+		interval = min(interval*2, maxInterval)
+	}
+}
@@ -0,0 +1,63 @@
+package resources
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/pkg/b2c"
+	"strings"
+)
+
+// errorDiagnostics describes a failed operation.  Errors returned by Microsoft Graph include the
+// request identifiers Microsoft support asks for.
+func errorDiagnostics(summary string, err error) diag.Diagnostics {
+	graphErr, ok := b2c.AsGraphError(err)
+	if !ok {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  summary,
+			Detail:   err.Error(),
+		}}
+	}
+
+	var detail strings.Builder
+	fmt.Fprintf(&detail, "Microsoft Graph returned status %d", graphErr.StatusCode)
+	if graphErr.Code != "" {
+		fmt.Fprintf(&detail, " (%s)", graphErr.Code)
+	}
+	if graphErr.Message != "" {
+		fmt.Fprintf(&detail, ": %s", graphErr.Message)
+	} else if graphErr.Body != "" {
+		fmt.Fprintf(&detail, ": %s", graphErr.Body)
+	}
+	if context := wrappingContext(err, graphErr); context != "" {
+		fmt.Fprintf(&detail, "\n\n%s", context)
+	}
+	if graphErr.RequestId != "" {
+		fmt.Fprintf(&detail, "\n\nrequest-id: %s", graphErr.RequestId)
+	}
+	if graphErr.ClientRequestId != "" {
+		fmt.Fprintf(&detail, "\nclient-request-id: %s", graphErr.ClientRequestId)
+	}
+	if graphErr.Date != "" {
+		fmt.Fprintf(&detail, "\ndate: %s", graphErr.Date)
+	}
+
+	return diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  summary,
+		Detail:   detail.String(),
+	}}
+}
+
+// wrappingContext returns what the errors wrapping a GraphError add to its message, so that the
+// message Microsoft Graph returned is only shown once.
+func wrappingContext(err error, graphErr *b2c.GraphError) string {
+	message := err.Error()
+	if message == graphErr.Error() {
+		return ""
+	}
+	if context, ok := strings.CutSuffix(message, ": "+graphErr.Error()); ok {
+		return context
+	}
+	return message
+}
@@ -0,0 +1,32 @@
+package resources
+
+import (
+	"fmt"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/pkg/b2c"
+	"strings"
+	"testing"
+)
+
+func TestErrorDiagnosticsShowsGraphMessageOnce(t *testing.T) {
+	graphErr := &b2c.GraphError{StatusCode: 409, Code: "AADB2C", Message: "The policy already exists", RequestId: "request-1"}
+	err := fmt.Errorf("policy B2C_1A_Base already exists in the tenant with different content: %w", graphErr)
+
+	diags := errorDiagnostics("Could not create Trust Framework Policy B2C_1A_Base", err)
+	if len(diags) != 1 {
+		t.Fatalf("expected one diagnostic, got %v", diags)
+	}
+	detail := diags[0].Detail
+	if count := strings.Count(detail, "The policy already exists"); count != 1 {
+		t.Fatalf("expected the Graph message once, got %d times in %q", count, detail)
+	}
+	expected := "Microsoft Graph returned status 409 (AADB2C): The policy already exists\n\n" +
+		"policy B2C_1A_Base already exists in the tenant with different content\n\n" +
+		"request-id: request-1"
+	if detail != expected {
+		t.Fatalf("unexpected detail %q", detail)
+	}
+
+	if detail := errorDiagnostics("Could not read", graphErr)[0].Detail; detail != "Microsoft Graph returned status 409 (AADB2C): The policy already exists\n\nrequest-id: request-1" {
+		t.Fatalf("unexpected detail %q", detail)
+	}
+}
@@ -0,0 +1,237 @@
+package resources
+
+import (
+	"context"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/client"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/util"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/pkg/b2c"
+	"net/http"
+	"sync"
+)
+
+// fakeTenant is an in-memory tenant implementing the policy and key set clients.
+type fakeTenant struct {
+	mu       sync.Mutex
+	policies map[string]string
+	keySets  map[string][]b2c.TrustFrameworkKey
+	// errors are returned by the methods they are keyed by instead of calling them.
+	errors map[string]error
+	calls  []string
+	// tenantId is the tenant_id the provider is configured with, if any.
+	tenantId string
+	// staleReads is the number of policy reads that return the policies as they were before their
+	// last upload, as Microsoft Graph may right after an upload.
+	staleReads int
+	previous   map[string]*string
+}
+
+var (
+	_ b2c.PolicyClient = (*fakeTenant)(nil)
+	_ b2c.KeySetClient = (*fakeTenant)(nil)
+)
+
+func newFakeTenant() *fakeTenant {
+	return &fakeTenant{
+		policies: map[string]string{},
+		keySets:  map[string][]b2c.TrustFrameworkKey{},
+		errors:   map[string]error{},
+		previous: map[string]*string{},
+	}
+}
+
+// upload stores a policy and remembers its previous XML for stale reads.
+func (f *fakeTenant) upload(name string, policyXml string) {
+	if previous, ok := f.policies[name]; ok {
+		f.previous[name] = &previous
+	} else {
+		f.previous[name] = nil
+	}
+	f.policies[name] = policyXml
+}
+
+// meta returns the provider meta of the tenant.
+func (f *fakeTenant) meta() *client.Client {
+	c := &client.Client{
+		TrustFrameworkPolicyClient: f,
+		TrustFrameworkKeySetClient: f,
+	}
+	if f.tenantId != "" {
+		c.Config = &client.MsGraphClientConfig{TenantID: f.tenantId}
+	}
+	return c
+}
+
+// call records a call and returns the error configured for it.
+func (f *fakeTenant) call(method string) error {
+	f.calls = append(f.calls, method)
+	return f.errors[method]
+}
+
+func notFound() error {
+	return &b2c.GraphError{StatusCode: http.StatusNotFound, Code: "AADB2C", Message: "not found"}
+}
+
+func (f *fakeTenant) Get(ctx context.Context, name string) (*b2c.Policy, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("Get"); err != nil {
+		return nil, err
+	}
+	xml, ok := f.policies[name]
+	if previous, uploaded := f.previous[name]; uploaded && f.staleReads > 0 {
+		f.staleReads--
+		xml, ok = "", previous != nil
+		if ok {
+			xml = *previous
+		}
+	}
+	if !ok {
+		return nil, notFound()
+	}
+	return &b2c.Policy{Name: name, Policy: xml}, nil
+}
+
+func (f *fakeTenant) List(options ...b2c.ListOption) *b2c.Pager[b2c.Policy] {
+	return b2c.NewPager(func(ctx context.Context, nextLink string) ([]b2c.Policy, string, error) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if err := f.call("List"); err != nil {
+			return nil, "", err
+		}
+		var policies []b2c.Policy
+		for name := range f.policies {
+			policies = append(policies, b2c.Policy{Name: name})
+		}
+		return policies, "", nil
+	})
+}
+
+func (f *fakeTenant) Create(ctx context.Context, policyXml *string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("Create"); err != nil {
+		return err
+	}
+	policy, err := util.ParseTrustFrameworkPolicy(*policyXml)
+	if err != nil {
+		return &b2c.GraphError{StatusCode: http.StatusBadRequest, Message: err.Error()}
+	}
+	if _, ok := f.policies[policy.PolicyId]; ok {
+		return &b2c.GraphError{StatusCode: http.StatusConflict, Message: "policy already exists"}
+	}
+	f.upload(policy.PolicyId, *policyXml)
+	return nil
+}
+
+func (f *fakeTenant) Update(ctx context.Context, policy *b2c.Policy) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("Update"); err != nil {
+		return err
+	}
+	f.upload(policy.Name, policy.Policy)
+	return nil
+}
+
+func (f *fakeTenant) Delete(ctx context.Context, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("Delete"); err != nil {
+		return err
+	}
+	if _, ok := f.policies[name]; !ok {
+		return notFound()
+	}
+	delete(f.policies, name)
+	return nil
+}
+
+func (f *fakeTenant) GetKeySet(ctx context.Context, id string) (*b2c.TrustFrameworkKeySet, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("GetKeySet"); err != nil {
+		return nil, err
+	}
+	keys, ok := f.keySets[id]
+	if !ok {
+		return nil, notFound()
+	}
+	return &b2c.TrustFrameworkKeySet{Id: &id, Keys: keys}, nil
+}
+
+func (f *fakeTenant) GetActiveKey(ctx context.Context, id string) (*b2c.TrustFrameworkKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("GetActiveKey"); err != nil {
+		return nil, err
+	}
+	keys := f.keySets[id]
+	if len(keys) == 0 {
+		return nil, notFound()
+	}
+	key := keys[len(keys)-1]
+	key.K = nil
+	return &key, nil
+}
+
+func (f *fakeTenant) ListKeySets(options ...b2c.ListOption) *b2c.Pager[b2c.TrustFrameworkKeySet] {
+	return b2c.NewPager(func(ctx context.Context, nextLink string) ([]b2c.TrustFrameworkKeySet, string, error) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if err := f.call("ListKeySets"); err != nil {
+			return nil, "", err
+		}
+		var keySets []b2c.TrustFrameworkKeySet
+		for id, keys := range f.keySets {
+			keySets = append(keySets, b2c.TrustFrameworkKeySet{Id: &id, Keys: keys})
+		}
+		return keySets, "", nil
+	})
+}
+
+func (f *fakeTenant) CreateKey(ctx context.Context, id string) (*b2c.TrustFrameworkKeySet, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("CreateKey"); err != nil {
+		return nil, err
+	}
+	if _, ok := f.keySets[id]; ok {
+		return nil, &b2c.GraphError{StatusCode: http.StatusConflict, Message: "key set already exists"}
+	}
+	f.keySets[id] = nil
+	return &b2c.TrustFrameworkKeySet{Id: &id}, nil
+}
+
+func (f *fakeTenant) addKey(method string, id string, key b2c.TrustFrameworkKey) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call(method); err != nil {
+		return err
+	}
+	if _, ok := f.keySets[id]; !ok {
+		return notFound()
+	}
+	f.keySets[id] = append(f.keySets[id], key)
+	return nil
+}
+
+func (f *fakeTenant) GenerateKey(ctx context.Context, id string, key b2c.TrustFrameworkKey) error {
+	return f.addKey("GenerateKey", id, key)
+}
+
+func (f *fakeTenant) UploadSecret(ctx context.Context, id string, key b2c.TrustFrameworkKey) error {
+	return f.addKey("UploadSecret", id, key)
+}
+
+func (f *fakeTenant) DeleteKey(ctx context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("DeleteKey"); err != nil {
+		return err
+	}
+	if _, ok := f.keySets[id]; !ok {
+		return notFound()
+	}
+	delete(f.keySets, id)
+	return nil
+}
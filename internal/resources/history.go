@@ -0,0 +1,160 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/client"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/pkg/b2c"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// previousPolicyVersion is the rollback_to version of the previous_policy attribute.
+const previousPolicyVersion = "previous"
+
+// policyVersionLayout names the versions of the policy history after the UTC time they were replaced.
+const policyVersionLayout = "20060102T150405.000Z"
+
+// policyHistory keeps the XML of policies before they are overwritten in a local directory, as
+// <directory>/<policy name>/<version>.xml.
+type policyHistory struct {
+	directory   string
+	maxVersions int
+}
+
+func policyHistoryOf(meta interface{}) *policyHistory {
+	config := meta.(*client.Client).Config
+	if config == nil || config.PolicyHistoryDirectory == "" {
+		return nil
+	}
+	return &policyHistory{directory: config.PolicyHistoryDirectory, maxVersions: config.PolicyHistoryMaxVersions}
+}
+
+// record stores a version of a policy and removes the oldest versions beyond maxVersions.
+func (h *policyHistory) record(name string, policyXml string, replaced time.Time) (string, error) {
+	directory := filepath.Join(h.directory, name)
+	if err := os.MkdirAll(directory, 0o755); err != nil {
+		return "", err
+	}
+	version := replaced.UTC().Format(policyVersionLayout)
+	if err := os.WriteFile(filepath.Join(directory, version+".xml"), []byte(policyXml), 0o644); err != nil {
+		return "", err
+	}
+
+	versions, err := h.versions(name)
+	if err != nil {
+		return "", err
+	}
+	for h.maxVersions > 0 && len(versions) > h.maxVersions {
+		if err := os.Remove(filepath.Join(directory, versions[0]+".xml")); err != nil {
+			return "", err
+		}
+		versions = versions[1:]
+	}
+	return version, nil
+}
+
+// versions returns the versions of a policy in the history, oldest first.
+func (h *policyHistory) versions(name string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(h.directory, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var versions []string
+	for _, entry := range entries {
+		version, ok := strings.CutSuffix(entry.Name(), ".xml")
+		if _, err := time.Parse(policyVersionLayout, version); ok && err == nil {
+			versions = append(versions, version)
+		}
+	}
+	sort.Strings(versions)
+	return versions, nil
+}
+
+func (h *policyHistory) read(name string, version string) (string, error) {
+	if _, err := time.Parse(policyVersionLayout, version); err != nil {
+		return "", fmt.Errorf("%q is not a version of the policy history, which are named like %s", version, policyVersionLayout)
+	}
+	policyXml, err := os.ReadFile(filepath.Join(h.directory, name, version+".xml"))
+	if err != nil {
+		return "", err
+	}
+	return string(policyXml), nil
+}
+
+// readPreviousPolicy reads the policy in the tenant before it is overwritten.  It returns nil when
+// the policy does not exist.
+func readPreviousPolicy(ctx context.Context, policyClient b2c.PolicyClient, name string) (*b2c.Policy, diag.Diagnostics) {
+	policy, err := policyClient.Get(ctx, name)
+	if err != nil {
+		if b2c.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errorDiagnostics(fmt.Sprintf("Could not read Trust Framework Policy %s before overwriting it", name), err)
+	}
+	return policy, nil
+}
+
+// keepPreviousPolicy keeps the policy overwritten by an update in previous_policy and the policy
+// history.  It is only called once the update succeeded, so a failed update neither replaces
+// previous_policy nor records a version the policy never moved away from.
+func keepPreviousPolicy(data *schema.ResourceData, meta interface{}, name string, previous *b2c.Policy) diag.Diagnostics {
+	if previous == nil {
+		return nil
+	}
+	data.Set("previous_policy", previous.Policy)
+
+	history := policyHistoryOf(meta)
+	if history == nil {
+		return nil
+	}
+	version, err := history.record(name, previous.Policy, time.Now())
+	if err != nil {
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  fmt.Sprintf("Could not record Trust Framework Policy %s in the policy history", name),
+			Detail:   fmt.Sprintf("The policy was updated, but the version it replaced is only kept in previous_policy: %s", err),
+		}}
+	}
+	log.Printf("[INFO] Recorded version %s of Trust Framework Policy %q in %s", version, name, history.directory)
+	return nil
+}
+
+// rollbackPolicy returns the version of a policy rollback_to names: the previous_policy of the state,
+// or a version of the policy history.
+func rollbackPolicy(data *schema.ResourceData, meta interface{}, name string, version string) (string, diag.Diagnostics) {
+	failed := func(detail string) (string, diag.Diagnostics) {
+		return "", diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  fmt.Sprintf("Could not roll back Trust Framework Policy %s to %s", name, version),
+			Detail:   detail,
+		}}
+	}
+
+	if version == previousPolicyVersion {
+		previous, _ := data.GetChange("previous_policy")
+		if previous.(string) == "" {
+			return failed("No previous version of the policy was captured, it has not been overwritten since it was created.")
+		}
+		return previous.(string), nil
+	}
+
+	history := policyHistoryOf(meta)
+	if history == nil {
+		return failed("Versions other than previous are read from the policy history, which is enabled with the provider's policy_history block.")
+	}
+	policyXml, err := history.read(name, version)
+	if err != nil {
+		return failed(err.Error())
+	}
+	return policyXml, nil
+}
@@ -0,0 +1,106 @@
+package resources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"strings"
+)
+
+type crudFunc = func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics
+
+// objectType describes the object a resource manages in diagnostics.
+type objectType struct {
+	resourceType string
+	description  string
+	nameKey      attribute.Key
+}
+
+var (
+	policyObject = objectType{
+		resourceType: policyResourceType,
+		description:  "Trust Framework Policy",
+		nameKey:      tracing.PolicyName,
+	}
+	keySetObject = objectType{
+		resourceType: keySetResourceType,
+		description:  "Trust Framework Key Set",
+		nameKey:      tracing.KeySetName,
+	}
+)
+
+// operationVerbs are the present participles of the timeout keys.
+var operationVerbs = map[string]string{
+	schema.TimeoutCreate: "creating",
+	schema.TimeoutRead:   "reading",
+	schema.TimeoutUpdate: "updating",
+	schema.TimeoutDelete: "deleting",
+}
+
+// operation runs a CRUD function in a span named after the resource type and the operation, which
+// becomes the parent of the spans of the Microsoft Graph requests it makes.  The SDK applies the
+// operation's timeout to the context, and running out of time is reported as a timeout of the
+// operation instead of the error of whichever request happened to be interrupted.
+func operation(object objectType, timeoutKey string, f crudFunc) crudFunc {
+	return func(ctx context.Context, data *schema.ResourceData, meta interface{}) diag.Diagnostics {
+		name := data.Id()
+		if name == "" {
+			name, _ = data.Get("name").(string)
+		}
+
+		ctx, span := tracing.Tracer().Start(ctx, object.resourceType+"."+timeoutKey, trace.WithAttributes(
+			tracing.ResourceType.String(object.resourceType),
+			object.nameKey.String(name),
+		))
+		defer span.End()
+
+		diags := f(ctx, data, meta)
+		if diags.HasError() && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			diags = timeoutDiagnostics(object, timeoutKey, name, data, diags)
+		}
+		for _, d := range diags {
+			if d.Severity == diag.Error {
+				span.SetStatus(codes.Error, d.Summary)
+				break
+			}
+		}
+		return diags
+	}
+}
+
+// timeoutDiagnostics replaces the errors of an operation which ran out of time with a single error
+// naming the operation and the object.  The details of the interrupted request are kept.
+func timeoutDiagnostics(object objectType, timeoutKey string, name string, data *schema.ResourceData, diags diag.Diagnostics) diag.Diagnostics {
+	var details []string
+	var result diag.Diagnostics
+	for _, d := range diags {
+		if d.Severity != diag.Error {
+			result = append(result, d)
+			continue
+		}
+		if d.Detail != "" {
+			details = append(details, d.Detail)
+		} else {
+			details = append(details, d.Summary)
+		}
+	}
+
+	detail := fmt.Sprintf("The %s operation of %s %q did not complete within its %s timeout. "+
+		"The timeout can be increased in the timeouts block of the %s resource.",
+		timeoutKey, object.description, name, data.Timeout(timeoutKey), object.resourceType)
+	if len(details) > 0 {
+		detail += "\n\n" + strings.Join(details, "\n\n")
+	}
+
+	return append(result, diag.Diagnostic{
+		Severity: diag.Error,
+		Summary:  fmt.Sprintf("Timed out %s %s %s", operationVerbs[timeoutKey], object.description, name),
+		Detail:   detail,
+	})
+}
@@ -0,0 +1,92 @@
+package resources
+
+import (
+	"context"
+	"errors"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOperationSpan(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	data := TrustFrameworkPolicyResource().TestResourceData()
+	data.Set("name", "B2C_1A_Test")
+
+	var operationSpan trace.SpanContext
+	create := operation(policyObject, schema.TimeoutCreate, func(ctx context.Context, data *schema.ResourceData, meta interface{}) diag.Diagnostics {
+		operationSpan = trace.SpanContextFromContext(ctx)
+		return diag.Errorf("Could not create Trust Framework Policy B2C_1A_Test")
+	})
+	create(context.Background(), data, nil)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected one span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "azureadb2cief_trust_framework_policy.create" || span.SpanContext.SpanID() != operationSpan.SpanID() {
+		t.Fatalf("expected the operation to run in the span, got %s", span.Name)
+	}
+	attributes := map[string]string{}
+	for _, kv := range span.Attributes {
+		attributes[string(kv.Key)] = kv.Value.AsString()
+	}
+	if attributes["azureadb2cief.policy.name"] != "B2C_1A_Test" || attributes["azureadb2cief.resource.type"] != policyResourceType {
+		t.Fatalf("unexpected attributes %v", attributes)
+	}
+	if span.Status.Code != codes.Error || span.Status.Description != "Could not create Trust Framework Policy B2C_1A_Test" {
+		t.Fatalf("expected the error diagnostic on the span, got %v", span.Status)
+	}
+}
+
+func TestOperationTimeout(t *testing.T) {
+	data := TrustFrameworkKeySetResource().TestResourceData()
+	data.SetId("B2C_1A_Stalled")
+
+	read := operation(keySetObject, schema.TimeoutRead, func(ctx context.Context, data *schema.ResourceData, meta interface{}) diag.Diagnostics {
+		<-ctx.Done()
+		return errorDiagnostics("Could not read Trust Framework Key Set B2C_1A_Stalled", errors.New("Microsoft Graph request GET /trustFramework/keySets/B2C_1A_Stalled did not complete: context deadline exceeded"))
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	diags := read(ctx, data, nil)
+
+	if len(diags) != 1 {
+		t.Fatalf("expected a single diagnostic, got %v", diags)
+	}
+	if diags[0].Summary != "Timed out reading Trust Framework Key Set B2C_1A_Stalled" {
+		t.Fatalf("unexpected summary %q", diags[0].Summary)
+	}
+	if !strings.Contains(diags[0].Detail, `The read operation of Trust Framework Key Set "B2C_1A_Stalled" did not complete within its`) ||
+		!strings.Contains(diags[0].Detail, "GET /trustFramework/keySets/B2C_1A_Stalled did not complete") {
+		t.Fatalf("unexpected detail %q", diags[0].Detail)
+	}
+}
+
+func TestOperationErrorWithoutTimeout(t *testing.T) {
+	data := TrustFrameworkKeySetResource().TestResourceData()
+	data.SetId("B2C_1A_Failed")
+
+	read := operation(keySetObject, schema.TimeoutRead, func(ctx context.Context, data *schema.ResourceData, meta interface{}) diag.Diagnostics {
+		return diag.Errorf("Could not read Trust Framework Key Set B2C_1A_Failed")
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if diags := read(ctx, data, nil); len(diags) != 1 || diags[0].Summary != "Could not read Trust Framework Key Set B2C_1A_Failed" {
+		t.Fatalf("expected the error to be reported unchanged, got %v", diags)
+	}
+}
@@ -0,0 +1,106 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/client"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/util"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/pkg/b2c"
+	"log"
+	"time"
+)
+
+// propagationOptions are how long and how often a policy is read after it is uploaded.
+type propagationOptions struct {
+	timeout     time.Duration
+	minInterval time.Duration
+	maxInterval time.Duration
+}
+
+func policyPropagation(meta interface{}) propagationOptions {
+	config := meta.(*client.Client).Config
+	if config == nil {
+		return propagationOptions{
+			timeout:     client.DefaultPolicyPropagationTimeout,
+			minInterval: client.DefaultPolicyPropagationMinInterval,
+			maxInterval: client.DefaultPolicyPropagationMaxInterval,
+		}
+	}
+	return propagationOptions{
+		timeout:     config.PolicyPropagationTimeout,
+		minInterval: config.PolicyPropagationMinInterval,
+		maxInterval: config.PolicyPropagationMaxInterval,
+	}
+}
+
+// withIntervalFloor returns the options with a minimum interval greater than zero, which would
+// otherwise read the policy in a busy loop, and a maximum interval no less than the minimum.
+func (o propagationOptions) withIntervalFloor() propagationOptions {
+	if o.minInterval <= 0 {
+		o.minInterval = client.DefaultPolicyPropagationMinInterval
+	}
+	if o.maxInterval < o.minInterval {
+		o.maxInterval = o.minInterval
+	}
+	return o
+}
+
+// waitForPolicy reads a policy that was just uploaded until Microsoft Graph returns the uploaded XML,
+// and returns the policy it read.  Reads right after an upload may still return the previous XML, or
+// not find a new policy, which would show as a diff in the next plan and fail the validation of
+// policies inheriting from it.  It returns no policy when it does not wait.
+func waitForPolicy(ctx context.Context, policyClient b2c.PolicyClient, name string, policyXml string, options propagationOptions) (*b2c.Policy, diag.Diagnostics) {
+	if options.timeout <= 0 {
+		return nil, nil
+	}
+	options = options.withIntervalFloor()
+	expected, err := util.CanonicalXml(policyXml)
+	if err != nil {
+		// The XML was validated at plan time, so this is not expected.
+		return nil, nil
+	}
+
+	start := time.Now()
+	deadline := time.NewTimer(options.timeout)
+	defer deadline.Stop()
+	interval := options.minInterval
+	reads := 0
+	for {
+		reads++
+		policy, err := policyClient.Get(ctx, name)
+		switch {
+		case err == nil:
+			if actual, err := util.CanonicalXml(policy.Policy); err == nil && actual == expected {
+				log.Printf("[DEBUG] Trust Framework Policy %q propagated after %s and %d reads", name, time.Since(start).Round(time.Millisecond), reads)
+				return policy, nil
+			}
+		case !b2c.IsNotFound(err):
+			return nil, errorDiagnostics(fmt.Sprintf("Could not read Trust Framework Policy %s after uploading it", name), err)
+		}
+
+		log.Printf("[DEBUG] Trust Framework Policy %q has not propagated yet, reading it again in %s", name, interval)
+		wait := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			wait.Stop()
+			return nil, diag.FromErr(ctx.Err())
+		case <-deadline.C:
+			wait.Stop()
+			return nil, diag.Diagnostics{{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("Timed out waiting for Trust Framework Policy %s to propagate", name),
+				Detail: fmt.Sprintf("Microsoft Graph accepted the policy, but %d reads over %s did not return the uploaded XML.  "+
+					"Microsoft Graph is eventually consistent and the policy may still propagate, the next plan shows whether it did.  "+
+					"The wait can be increased with the timeout of the provider's policy_propagation block.",
+					reads, options.timeout),
+			}}
+		case <-wait.C:
+		}
+
+		interval *= 2
+		if interval > options.maxInterval {
+			interval = options.maxInterval
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"strings"
+	"testing"
+)
+
+// protocolProvider serves the resources through the provider protocol, as Terraform calls them, so
+// that tests see what only exists there, such as the raw configuration, write-only attributes and
+// plan-time checks.
+type protocolProvider struct {
+	t        *testing.T
+	provider *schema.Provider
+	server   tfprotov5.ProviderServer
+	schemas  map[string]*tfprotov5.Schema
+}
+
+func newProtocolProvider(t *testing.T, tenant *fakeTenant) *protocolProvider {
+	p := &schema.Provider{
+		ResourcesMap: map[string]*schema.Resource{
+			policyResourceType: TrustFrameworkPolicyResource(),
+			keySetResourceType: TrustFrameworkKeySetResource(),
+		},
+	}
+	p.SetMeta(tenant.meta())
+	server := schema.NewGRPCProviderServer(p)
+
+	resp, err := server.GetProviderSchema(context.Background(), &tfprotov5.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &protocolProvider{t: t, provider: p, server: server, schemas: resp.ResourceSchemas}
+}
+
+// config returns a configuration of a resource with the given attributes and every other attribute null.
+func (p *protocolProvider) config(typeName string, attributes map[string]tftypes.Value) tftypes.Value {
+	objectType := p.schemas[typeName].ValueType().(tftypes.Object)
+	values := map[string]tftypes.Value{}
+	for name, attributeType := range objectType.AttributeTypes {
+		if value, ok := attributes[name]; ok {
+			values[name] = value
+		} else {
+			values[name] = tftypes.NewValue(attributeType, nil)
+		}
+	}
+	return tftypes.NewValue(objectType, values)
+}
+
+// null returns the null state of a resource that does not exist.
+func (p *protocolProvider) null(typeName string) tftypes.Value {
+	return tftypes.NewValue(p.schemas[typeName].ValueType(), nil)
+}
+
+// proposedNewState merges the configuration with the computed attributes of the prior state, and
+// nulls write-only attributes, as Terraform does before planning.
+func (p *protocolProvider) proposedNewState(typeName string, prior tftypes.Value, config tftypes.Value) tftypes.Value {
+	var priorAttributes, configAttributes map[string]tftypes.Value
+	if !prior.IsNull() {
+		p.must(prior.As(&priorAttributes))
+	}
+	p.must(config.As(&configAttributes))
+
+	proposed := map[string]tftypes.Value{}
+	for _, attribute := range p.schemas[typeName].Block.Attributes {
+		value := configAttributes[attribute.Name]
+		switch {
+		case attribute.WriteOnly:
+			value = tftypes.NewValue(attribute.Type, nil)
+		case value.IsNull() && attribute.Computed && priorAttributes != nil:
+			value = priorAttributes[attribute.Name]
+		}
+		proposed[attribute.Name] = value
+	}
+	for _, block := range p.schemas[typeName].Block.BlockTypes {
+		proposed[block.TypeName] = configAttributes[block.TypeName]
+	}
+	return tftypes.NewValue(config.Type(), proposed)
+}
+
+func (p *protocolProvider) must(err error) {
+	p.t.Helper()
+	if err != nil {
+		p.t.Fatal(err)
+	}
+}
+
+func (p *protocolProvider) dynamicValue(value tftypes.Value) *tfprotov5.DynamicValue {
+	p.t.Helper()
+	dynamicValue, err := tfprotov5.NewDynamicValue(value.Type(), value)
+	p.must(err)
+	return &dynamicValue
+}
+
+// plan validates the configuration and plans the change from prior to config.
+func (p *protocolProvider) plan(typeName string, prior tftypes.Value, config tftypes.Value) (*tfprotov5.PlanResourceChangeResponse, []*tfprotov5.Diagnostic) {
+	p.t.Helper()
+	ctx := context.Background()
+
+	validated, err := p.server.ValidateResourceTypeConfig(ctx, &tfprotov5.ValidateResourceTypeConfigRequest{
+		TypeName: typeName,
+		Config:   p.dynamicValue(config),
+		ClientCapabilities: &tfprotov5.ValidateResourceTypeConfigClientCapabilities{
+			WriteOnlyAttributesAllowed: true,
+		},
+	})
+	p.must(err)
+	if hasError(validated.Diagnostics) {
+		return nil, validated.Diagnostics
+	}
+
+	planned, err := p.server.PlanResourceChange(ctx, &tfprotov5.PlanResourceChangeRequest{
+		TypeName:         typeName,
+		PriorState:       p.dynamicValue(prior),
+		ProposedNewState: p.dynamicValue(p.proposedNewState(typeName, prior, config)),
+		Config:           p.dynamicValue(config),
+	})
+	p.must(err)
+	return planned, planned.Diagnostics
+}
+
+// apply plans and applies the change from prior to config, and returns the new state.
+func (p *protocolProvider) apply(typeName string, prior tftypes.Value, config tftypes.Value) (tftypes.Value, []*tfprotov5.Diagnostic) {
+	p.t.Helper()
+	planned, diags := p.plan(typeName, prior, config)
+	if hasError(diags) {
+		return prior, diags
+	}
+
+	applied, err := p.server.ApplyResourceChange(context.Background(), &tfprotov5.ApplyResourceChangeRequest{
+		TypeName:       typeName,
+		PriorState:     p.dynamicValue(prior),
+		PlannedState:   planned.PlannedState,
+		Config:         p.dynamicValue(config),
+		PlannedPrivate: planned.PlannedPrivate,
+	})
+	p.must(err)
+	if applied.NewState == nil {
+		return prior, applied.Diagnostics
+	}
+
+	state, err := applied.NewState.Unmarshal(p.schemas[typeName].ValueType())
+	p.must(err)
+	return state, applied.Diagnostics
+}
+
+// attribute returns an attribute of a state.
+func (p *protocolProvider) attribute(state tftypes.Value, name string) tftypes.Value {
+	var attributes map[string]tftypes.Value
+	p.must(state.As(&attributes))
+	return attributes[name]
+}
+
+// diagnosticsString describes diagnostics in test failures.
+func diagnosticsString(diags []*tfprotov5.Diagnostic) string {
+	var b strings.Builder
+	for _, d := range diags {
+		fmt.Fprintf(&b, "[%s] %s: %s\n", d.Severity, d.Summary, d.Detail)
+	}
+	return b.String()
+}
+
+func hasError(diags []*tfprotov5.Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == tfprotov5.DiagnosticSeverityError {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,12 @@
+package resources
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"strings"
+)
+
+// notCaseSensitive ignores changes to the case of names, which Microsoft Graph matches without regard
+// to case.
+func notCaseSensitive(_, old, new string, _ *schema.ResourceData) bool {
+	return strings.EqualFold(old, new)
+}
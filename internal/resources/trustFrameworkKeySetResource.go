@@ -3,16 +3,18 @@ package resources
 import (
 	"context"
 	"fmt"
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/client"
-	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/models"
-	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/util"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/pkg/b2c"
 	"log"
-	"net/http"
 	"strings"
+	"time"
 )
 
+const keySetResourceType = "azureadb2cief_trust_framework_key_set"
+
 func TrustFrameworkKeySetResource() *schema.Resource {
 	return &schema.Resource{
 		Schema: map[string]*schema.Schema{
@@ -47,7 +49,7 @@ func TrustFrameworkKeySetResource() *schema.Resource {
 					errors = append(errors, err)
 					return
 				},
-				DiffSuppressFunc: util.NotCaseSensitive,
+				DiffSuppressFunc: notCaseSensitive,
 			},
 			"kty": {
 				Type:        schema.TypeString,
@@ -65,16 +67,31 @@ func TrustFrameworkKeySetResource() *schema.Resource {
 					errors = append(errors, err)
 					return
 				},
-				DiffSuppressFunc: util.NotCaseSensitive,
+				DiffSuppressFunc: notCaseSensitive,
 			},
 			"k": {
-				Description: "The optional secret value to upload.",
+				Description: "The optional secret value to upload.  It is stored in the state, use `k_wo` to keep it out of the state.",
 				Type:        schema.TypeString,
 				Optional:    true,
 				ForceNew:    true,
 				Sensitive:   true,
 				Computed:    true,
 			},
+			"k_wo": {
+				Description:   "The optional secret value to upload, which is never stored in the plan or state and can come from an ephemeral resource.  Requires Terraform 1.11 or later.  Change `k_wo_version` to upload a new value.",
+				Type:          schema.TypeString,
+				Optional:      true,
+				WriteOnly:     true,
+				Sensitive:     true,
+				ConflictsWith: []string{"k"},
+			},
+			"k_wo_version": {
+				Description:  "The version of `k_wo`.  Changing it uploads the value of `k_wo` as the new active key of the key set.",
+				Type:         schema.TypeInt,
+				Optional:     true,
+				RequiredWith: []string{"k_wo"},
+			},
+			"adopt_existing": adoptExistingSchema(keySetObject),
 			"nbf": {
 				Type:        schema.TypeInt,
 				Optional:    true,
@@ -88,9 +105,16 @@ func TrustFrameworkKeySetResource() *schema.Resource {
 				Description: "Expiration date, this value is a NumericDate as defined in RFC 7519.",
 			},
 		},
-		CreateContext: createKey,
-		ReadContext:   readKey,
-		DeleteContext: deleteKey,
+		CreateContext: operation(keySetObject, schema.TimeoutCreate, createKey),
+		ReadContext:   operation(keySetObject, schema.TimeoutRead, readKey),
+		UpdateContext: operation(keySetObject, schema.TimeoutUpdate, updateKey),
+		DeleteContext: operation(keySetObject, schema.TimeoutDelete, deleteKey),
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
@@ -101,9 +125,9 @@ func TrustFrameworkKeySetResource() *schema.Resource {
 func deleteKey(ctx context.Context, data *schema.ResourceData, i interface{}) diag.Diagnostics {
 	keySetClient := i.(*client.Client).TrustFrameworkKeySetClient
 
-	_, err := keySetClient.DeleteKey(ctx, data.Id())
+	err := keySetClient.DeleteKey(ctx, data.Id())
 	if err != nil {
-		return diag.FromErr(err)
+		return errorDiagnostics(fmt.Sprintf("Could not delete Trust Framework Key Set %s", data.Id()), err)
 	}
 
 	data.SetId("")
@@ -115,15 +139,15 @@ func readKey(ctx context.Context, data *schema.ResourceData, i interface{}) diag
 
 	id := data.Id()
 
-	keyset, stat, err := keySetClient.GetKeySet(ctx, id)
+	keyset, err := keySetClient.GetKeySet(ctx, id)
 
 	if err != nil {
-		if stat == http.StatusNotFound {
+		if b2c.IsNotFound(err) {
 			log.Printf("[DEBUG] Trust Framework Key Set with Name %q was not found - removing from state", data.Id())
 			data.SetId("")
 			return nil
 		}
-		return diag.FromErr(err)
+		return errorDiagnostics(fmt.Sprintf("Could not read Trust Framework Key Set %s", id), err)
 	}
 	data.Set("name", id)
 
@@ -131,14 +155,14 @@ func readKey(ctx context.Context, data *schema.ResourceData, i interface{}) diag
 		return nil
 	}
 
-	key, stat, err := keySetClient.GetActiveKey(ctx, id)
+	key, err := keySetClient.GetActiveKey(ctx, id)
 	if err != nil {
-		if stat == http.StatusNotFound {
+		if b2c.IsNotFound(err) {
 			log.Printf("[DEBUG] Trust Framework Key Set with Name %q was not found - removing from state", data.Id())
 			data.SetId("")
 			return nil
 		}
-		return diag.FromErr(err)
+		return errorDiagnostics(fmt.Sprintf("Could not read Trust Framework Key Set %s", id), err)
 	}
 
 	data.Set("use", key.Use)
@@ -154,6 +178,63 @@ func readKey(ctx context.Context, data *schema.ResourceData, i interface{}) diag
 	return nil
 }
 
+// writeOnlySecret returns the value of k_wo.  Write-only values are only available in the
+// configuration of the apply that uses them.
+func writeOnlySecret(data *schema.ResourceData) (string, bool) {
+	config := data.GetRawConfig()
+	if config.IsNull() || !config.IsKnown() {
+		return "", false
+	}
+	value := config.GetAttr("k_wo")
+	if value.IsNull() || !value.IsKnown() {
+		return "", false
+	}
+	return value.AsString(), true
+}
+
+// updateKey uploads the value of k_wo as the new active key of the key set when k_wo_version
+// changes, which is the only argument that can change without replacing the key set.
+func updateKey(ctx context.Context, data *schema.ResourceData, i interface{}) diag.Diagnostics {
+	keySetClient := i.(*client.Client).TrustFrameworkKeySetClient
+	id := data.Id()
+
+	if data.HasChange("k_wo_version") {
+		secret, ok := writeOnlySecret(data)
+		if !ok {
+			return diag.Diagnostics{
+				{
+					Severity:      diag.Error,
+					Summary:       fmt.Sprintf("No secret to upload to Trust Framework Key Set %s", id),
+					Detail:        "k_wo_version changed, but k_wo is not set.  Set k_wo to the secret to upload.",
+					AttributePath: cty.GetAttrPath("k_wo"),
+				},
+			}
+		}
+
+		kty := data.Get("kty").(string)
+		use := data.Get("use").(string)
+		key := b2c.TrustFrameworkKey{
+			Kty: &kty,
+			Use: &use,
+			K:   &secret,
+		}
+		if expRaw, ok := data.GetOk("exp"); ok {
+			exp := expRaw.(int)
+			key.Exp = &exp
+		}
+		if nbfRaw, ok := data.GetOk("nbf"); ok {
+			nbf := nbfRaw.(int)
+			key.Nbf = &nbf
+		}
+
+		if err := keySetClient.UploadSecret(ctx, id, key); err != nil {
+			return errorDiagnostics(fmt.Sprintf("Could not upload the secret for Trust Framework Key Set %s", id), err)
+		}
+	}
+
+	return readKey(ctx, data, i)
+}
+
 func createKey(ctx context.Context, data *schema.ResourceData, i interface{}) diag.Diagnostics {
 	keySetClient := i.(*client.Client).TrustFrameworkKeySetClient
 
@@ -161,7 +242,7 @@ func createKey(ctx context.Context, data *schema.ResourceData, i interface{}) di
 	kty := data.Get("kty").(string)
 	use := data.Get("use").(string)
 
-	key := models.TrustFrameworkKey{
+	key := b2c.TrustFrameworkKey{
 		Kty: &kty,
 		Use: &use,
 	}
@@ -171,6 +252,10 @@ func createKey(ctx context.Context, data *schema.ResourceData, i interface{}) di
 		key.K = &secret
 		specifiedSecret = true
 	}
+	if secret, ok := writeOnlySecret(data); ok {
+		key.K = &secret
+		specifiedSecret = true
+	}
 
 	if expRaw, ok := data.GetOk("exp"); ok {
 		exp := expRaw.(int)
@@ -181,21 +266,31 @@ func createKey(ctx context.Context, data *schema.ResourceData, i interface{}) di
 		key.Nbf = &nbf
 	}
 
-	keyset, _, err := keySetClient.CreateKey(ctx, name)
+	var diags diag.Diagnostics
+	keyset, err := keySetClient.CreateKey(ctx, name)
 	if err != nil {
-		return diag.FromErr(err)
+		if !b2c.IsConflict(err) || !adoptExisting(data, i) {
+			return errorDiagnostics(fmt.Sprintf("Could not create Trust Framework Key Set %s", name), err)
+		}
+		keyset, diags = adoptKeySet(ctx, keySetClient, name, use, kty, specifiedSecret)
+		if diags.HasError() {
+			return diags
+		}
 	}
 	data.SetId(*keyset.Id)
 
+	// An adopted key set keeps its active key unless a secret is configured.
 	if specifiedSecret {
-		_, err = keySetClient.UploadSecret(ctx, *keyset.Id, key)
-	} else {
-		_, err = keySetClient.GenerateKey(ctx, *keyset.Id, key)
-	}
-
-	if err != nil {
-		return diag.FromErr(err)
+		err = keySetClient.UploadSecret(ctx, *keyset.Id, key)
+		if err != nil {
+			return append(diags, errorDiagnostics(fmt.Sprintf("Could not upload the secret for Trust Framework Key Set %s", name), err)...)
+		}
+	} else if len(keyset.Keys) == 0 {
+		err = keySetClient.GenerateKey(ctx, *keyset.Id, key)
+		if err != nil {
+			return append(diags, errorDiagnostics(fmt.Sprintf("Could not generate a key for Trust Framework Key Set %s", name), err)...)
+		}
 	}
 
-	return readKey(ctx, data, i)
+	return append(diags, readKey(ctx, data, i)...)
 }
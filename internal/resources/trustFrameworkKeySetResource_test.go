@@ -3,7 +3,6 @@ package resources_test
 import (
 	"context"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/acceptance"
 	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/client"
@@ -14,11 +13,7 @@ func TestAccTrustFrameworkKeyset_basic(t *testing.T) {
 	resourceName := "azureadb2cief_trust_framework_key_set.TestKeySet"
 
 	resource.Test(t, resource.TestCase{
-		ProviderFactories: map[string]func() (*schema.Provider, error){
-			"azureadb2cief": func() (*schema.Provider, error) {
-				return acceptance.AzureADB2CProvider, nil
-			},
-		},
+		ProtoV5ProviderFactories: acceptance.ProtoV5ProviderFactories,
 		ExternalProviders: map[string]resource.ExternalProvider{
 			"time": {
 				Source:            "hashicorp/time",
@@ -46,11 +41,7 @@ func TestAccTrustFrameworkKeyset_nonbfexp(t *testing.T) {
 	resourceName := "azureadb2cief_trust_framework_key_set.TestKeySet"
 
 	resource.Test(t, resource.TestCase{
-		ProviderFactories: map[string]func() (*schema.Provider, error){
-			"azureadb2cief": func() (*schema.Provider, error) {
-				return acceptance.AzureADB2CProvider, nil
-			},
-		},
+		ProtoV5ProviderFactories: acceptance.ProtoV5ProviderFactories,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccTrustFrameworkKeyset2,
@@ -72,11 +63,7 @@ func TestAccTrustFrameworkKeyset_basic2(t *testing.T) {
 	resourceName := "azureadb2cief_trust_framework_key_set.TestKeySet"
 
 	resource.Test(t, resource.TestCase{
-		ProviderFactories: map[string]func() (*schema.Provider, error){
-			"azureadb2cief": func() (*schema.Provider, error) {
-				return acceptance.AzureADB2CProvider, nil
-			},
-		},
+		ProtoV5ProviderFactories: acceptance.ProtoV5ProviderFactories,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccTrustFrameworkKeyset3,
@@ -98,11 +85,7 @@ func TestAccTrustFrameworkKeyset_UploadSecret(t *testing.T) {
 	resourceName := "azureadb2cief_trust_framework_key_set.TestKeySetWithSecret"
 
 	resource.Test(t, resource.TestCase{
-		ProviderFactories: map[string]func() (*schema.Provider, error){
-			"azureadb2cief": func() (*schema.Provider, error) {
-				return acceptance.AzureADB2CProvider, nil
-			},
-		},
+		ProtoV5ProviderFactories: acceptance.ProtoV5ProviderFactories,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccTrustFrameworkKeysetWithSecret,
@@ -125,7 +108,7 @@ func checkTrustFrameworkKeySetExists(resourceName string) resource.TestCheckFunc
 	return func(state *terraform.State) error {
 		keySetClient := acceptance.AzureADB2CProvider.Meta().(*client.Client).TrustFrameworkKeySetClient
 		id := state.RootModule().Resources[resourceName].Primary.ID
-		_, _, err := keySetClient.GetActiveKey(context.TODO(), id)
+		_, err := keySetClient.GetActiveKey(context.TODO(), id)
 		if err != nil {
 			return err
 		}
@@ -135,11 +118,7 @@ func checkTrustFrameworkKeySetExists(resourceName string) resource.TestCheckFunc
 }
 func TestAccTrustFrameworkKeyset_mixedCasesDiffIgnore(t *testing.T) {
 	resource.Test(t, resource.TestCase{
-		ProviderFactories: map[string]func() (*schema.Provider, error){
-			"azureadb2cief": func() (*schema.Provider, error) {
-				return acceptance.AzureADB2CProvider, nil
-			},
-		},
+		ProtoV5ProviderFactories: acceptance.ProtoV5ProviderFactories,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccTrustFrameworkKeyset3,
@@ -3,27 +3,31 @@ package resources
 import (
 	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/client"
-	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/models"
 	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/util"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/pkg/b2c"
 	"io"
 	"log"
-	"net/http"
+	"net/url"
 	"strings"
+	"time"
 )
 
+const policyResourceType = "azureadb2cief_trust_framework_policy"
+
 func TrustFrameworkPolicyResource() *schema.Resource {
 	return &schema.Resource{
 		Schema: map[string]*schema.Schema{
 			"name": {
 				Type:        schema.TypeString,
-				Required:    true,
-				Description: "The name of the policy.  The name must begin with B2C_1A_",
-				Computed:    false,
+				Optional:    true,
+				Description: "The name of the policy.  The name must begin with B2C_1A_ and match the PolicyId of the policy XML, from which it is taken when it is not set",
+				Computed:    true,
 				ForceNew:    true,
 				ValidateFunc: func(i interface{}, s string) (warnings []string, errors []error) {
 					name := i.(string)
@@ -34,35 +38,65 @@ func TrustFrameworkPolicyResource() *schema.Resource {
 					errors = append(errors, fmt.Errorf("custom_policy_key_set name (%s) must begin with B2C_1A_", name))
 					return
 				},
-				DiffSuppressFunc: util.NotCaseSensitive,
+				DiffSuppressFunc: notCaseSensitive,
 			},
+			"adopt_existing": adoptExistingSchema(policyObject),
 			"policy": {
 				Description:      "The policy XML",
 				Type:             schema.TypeString,
 				Required:         true,
-				DiffSuppressFunc: util.XmlDiff,
+				DiffSuppressFunc: policyDiffSuppress,
 				ValidateDiagFunc: policyXmlValidate,
 			},
+			"previous_policy": {
+				Description: "The policy XML in the tenant before it was last overwritten by this resource",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"rollback_to": {
+				Description: "Uploads a previous version of the policy instead of `policy`: `previous` for `previous_policy`, or a version of the provider's `policy_history`.  " +
+					"Changes to `policy` are ignored while it is set, remove it to upload `policy` again",
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"force_delete": {
+				Description: "Delete the policy even when other policies of the tenant inherit from it.  It must be applied before the policy is destroyed",
+				Type:        schema.TypeBool,
+				Optional:    true,
+			},
 		},
 		SchemaVersion: 1,
-		CreateContext: policyResourceCreate,
-		ReadContext:   policyResourceRead,
-		UpdateContext: policyResourceUpdate,
-		DeleteContext: policyResourceDelete,
+		CustomizeDiff: policyCustomizeDiff,
+		CreateContext: operation(policyObject, schema.TimeoutCreate, policyResourceCreate),
+		ReadContext:   operation(policyObject, schema.TimeoutRead, policyResourceRead),
+		UpdateContext: operation(policyObject, schema.TimeoutUpdate, policyResourceUpdate),
+		DeleteContext: operation(policyObject, schema.TimeoutDelete, policyResourceDelete),
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
-		Timeouts:    nil,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
 		Description: "",
 	}
 }
 
 func policyResourceDelete(ctx context.Context, data *schema.ResourceData, i interface{}) diag.Diagnostics {
-	policyClient := i.(*client.Client).TrustFrameworkPolicyClient
+	apiClient := i.(*client.Client)
+	policyClient := apiClient.TrustFrameworkPolicyClient
 
-	_, err := policyClient.Delete(ctx, data.Id())
+	if !data.Get("force_delete").(bool) {
+		if diags := waitForDependentPolicies(ctx, apiClient, data.Id(), defaultDependentPoliciesWait); diags.HasError() {
+			return diags
+		}
+	}
+
+	err := policyClient.Delete(ctx, data.Id())
 	if err != nil {
-		return diag.FromErr(err)
+		return errorDiagnostics(fmt.Sprintf("Could not delete Trust Framework Policy %s", data.Id()), err)
 	}
 	return nil
 }
@@ -71,18 +105,42 @@ func policyResourceUpdate(ctx context.Context, data *schema.ResourceData, i inte
 	policyClient := i.(*client.Client).TrustFrameworkPolicyClient
 	id := data.Id()
 
-	if data.HasChange("policy") {
+	if data.HasChanges("policy", "rollback_to") {
 		xml := data.Get("policy").(string)
+		if version := data.Get("rollback_to").(string); version != "" {
+			rollbackXml, diags := rollbackPolicy(data, i, id, version)
+			if diags.HasError() {
+				return diags
+			}
+			xml = rollbackXml
+			log.Printf("[INFO] Rolling back Trust Framework Policy %q to %s", id, version)
+		}
+		previous, diags := readPreviousPolicy(ctx, policyClient, id)
+		if diags.HasError() {
+			return diags
+		}
 
-		policy := models.Policy{
+		policy := b2c.Policy{
 			Name:   id,
 			Policy: xml,
 		}
-		_, err := policyClient.Update(ctx, &policy)
+		err := policyClient.Update(ctx, &policy)
 
 		if err != nil {
-			return diag.FromErr(err)
+			return errorDiagnostics(fmt.Sprintf("Could not update Trust Framework Policy %s", id), err)
 		}
+		i.(*client.Client).ForgetBasePolicy(id)
+		diags = append(diags, keepPreviousPolicy(data, i, id, previous)...)
+		propagated, waitDiags := waitForPolicy(ctx, policyClient, id, xml, policyPropagation(i))
+		diags = append(diags, waitDiags...)
+		if diags.HasError() {
+			return diags
+		}
+		if propagated != nil {
+			setPolicy(data, propagated)
+			return diags
+		}
+		return append(diags, policyResourceRead(ctx, data, i)...)
 	}
 
 	return policyResourceRead(ctx, data, i)
@@ -94,32 +152,167 @@ func policyResourceCreate(ctx context.Context, data *schema.ResourceData, i inte
 	id := data.Get("name").(string)
 	xml := data.Get("policy").(string)
 
-	_, err := policyClient.Create(ctx, &xml)
+	var diags diag.Diagnostics
+	err := policyClient.Create(ctx, &xml)
 
 	if err != nil {
-		return diag.FromErr(err)
+		if !b2c.IsConflict(err) || !adoptExisting(data, i) {
+			return errorDiagnostics(fmt.Sprintf("Could not create Trust Framework Policy %s", id), err)
+		}
+		previous, readDiags := readPreviousPolicy(ctx, policyClient, id)
+		if readDiags.HasError() {
+			return readDiags
+		}
+		policy := b2c.Policy{
+			Name:   id,
+			Policy: xml,
+		}
+		if err := policyClient.Update(ctx, &policy); err != nil {
+			return errorDiagnostics(fmt.Sprintf("Could not adopt the existing Trust Framework Policy %s", id), err)
+		}
+		i.(*client.Client).ForgetBasePolicy(id)
+		diags = append(diags, keepPreviousPolicy(data, i, id, previous)...)
+		diags = append(diags, adoptedWarning(policyObject, id, "Its XML was replaced with the configured policy."))
 	}
 
 	data.SetId(id)
-	return policyResourceRead(ctx, data, i)
+	propagated, waitDiags := waitForPolicy(ctx, policyClient, id, xml, policyPropagation(i))
+	diags = append(diags, waitDiags...)
+	if diags.HasError() {
+		return diags
+	}
+	if propagated != nil {
+		setPolicy(data, propagated)
+		return diags
+	}
+	return append(diags, policyResourceRead(ctx, data, i)...)
 }
 
 func policyResourceRead(ctx context.Context, data *schema.ResourceData, i interface{}) diag.Diagnostics {
 	policyClient := i.(*client.Client).TrustFrameworkPolicyClient
 
-	policy, status, err := policyClient.Get(ctx, data.Id())
+	policy, err := policyClient.Get(ctx, data.Id())
 	if err != nil {
-		if status == http.StatusNotFound {
+		if b2c.IsNotFound(err) {
 			log.Printf("[DEBUG] Trust Framework Policy with Name %q was not found - removing from state", data.Id())
 			data.SetId("")
 			return nil
 		}
-		return diag.FromErr(err)
+		return errorDiagnostics(fmt.Sprintf("Could not read Trust Framework Policy %s", data.Id()), err)
 	}
 
+	setPolicy(data, policy)
+	return nil
+}
+
+func setPolicy(data *schema.ResourceData, policy *b2c.Policy) {
 	data.Set("policy", policy.Policy)
 	data.Set("name", policy.Name)
-	return nil
+}
+
+// policyDiffSuppress compares policies with EqualXml, and ignores changes to the policy of an existing
+// resource while it is rolled back.
+func policyDiffSuppress(k, old, new string, d *schema.ResourceData) bool {
+	if d.Id() != "" && rollingBack(d.GetRawConfig()) {
+		return true
+	}
+	return util.EqualXml(old, new)
+}
+
+// rollingBack reports whether rollback_to is set in the configuration.  Get falls back to the state
+// when it is removed from the configuration.
+func rollingBack(config cty.Value) bool {
+	if config.IsNull() || !config.IsKnown() {
+		return false
+	}
+	rollbackTo := config.GetAttr("rollback_to")
+	return !rollbackTo.IsKnown() || !rollbackTo.IsNull() && rollbackTo.AsString() != ""
+}
+
+// policyCustomizeDiff checks at plan time that the policy XML names the policy and the tenant it is
+// uploaded to.  Microsoft Graph creates the policy named by the PolicyId of the XML whatever the name
+// of the resource, so a mismatch would leave the resource tracking a policy that does not exist.
+func policyCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	// A new policy has neither a previous policy nor a history to roll back to.
+	if d.Id() == "" && d.Get("rollback_to").(string) != "" {
+		return fmt.Errorf("rollback_to can only be set on a policy that was already created, remove it to create the policy")
+	}
+
+	// The policy in the tenant is captured before it is overwritten.  ResourceDiff reports changes
+	// that policyDiffSuppress ignores, so they are compared the same way.
+	policyChanged := false
+	if d.HasChange("policy") && !rollingBack(d.GetRawConfig()) {
+		old, new := d.GetChange("policy")
+		policyChanged = !util.EqualXml(old.(string), new.(string))
+	}
+	if d.Id() != "" && (policyChanged || d.HasChange("rollback_to")) {
+		if err := d.SetNewComputed("previous_policy"); err != nil {
+			return err
+		}
+	}
+
+	if !d.NewValueKnown("policy") {
+		return nil
+	}
+	policy, err := util.ParseTrustFrameworkPolicy(d.Get("policy").(string))
+	if err != nil {
+		return fmt.Errorf("invalid policy XML: %s", err)
+	}
+
+	var problems []error
+	if strings.Index(strings.ToUpper(policy.PolicyId), "B2C_1A_") != 0 {
+		problems = append(problems, fmt.Errorf("the PolicyId %q of the policy XML must begin with B2C_1A_", policy.PolicyId))
+	}
+
+	if d.GetRawConfig().GetAttr("name").IsNull() {
+		if !strings.EqualFold(d.Get("name").(string), policy.PolicyId) {
+			if err := d.SetNew("name", policy.PolicyId); err != nil {
+				return err
+			}
+		}
+	} else if name := d.Get("name").(string); d.NewValueKnown("name") && !strings.EqualFold(name, policy.PolicyId) {
+		problems = append(problems, fmt.Errorf("the PolicyId %q of the policy XML does not match the name %q.  Microsoft Graph names the policy after its PolicyId", policy.PolicyId, name))
+	}
+
+	if apiClient, ok := meta.(*client.Client); ok {
+		// A failure to read the domains is reported once with a warning by the provider server, as
+		// CustomizeDiff can only fail the plan.
+		domains, _ := apiClient.TenantDomains(ctx)
+		problems = append(problems, checkPolicyTenant(policy, domains)...)
+	}
+	return errors.Join(problems...)
+}
+
+// checkPolicyTenant checks that a policy is uploaded to the tenant it names, and that its base policy
+// is in the same tenant.  It checks nothing when the domains of the tenant are not known.
+func checkPolicyTenant(policy *util.TrustFrameworkPolicy, domains []string) []error {
+	if len(domains) == 0 {
+		return nil
+	}
+	inTenant := func(domain string) bool {
+		for _, d := range domains {
+			if strings.EqualFold(d, domain) {
+				return true
+			}
+		}
+		return false
+	}
+	tenant := strings.Join(domains, ", ")
+
+	var problems []error
+	if policy.TenantId != "" && !inTenant(policy.TenantId) {
+		problems = append(problems, fmt.Errorf("the TenantId %q of the policy XML is not a domain of the configured tenant (%s)", policy.TenantId, tenant))
+	}
+	if policy.PublicPolicyUri != "" {
+		uri, err := url.Parse(policy.PublicPolicyUri)
+		if err != nil || !inTenant(uri.Hostname()) {
+			problems = append(problems, fmt.Errorf("the PublicPolicyUri %q of the policy XML is not in a domain of the configured tenant (%s)", policy.PublicPolicyUri, tenant))
+		}
+	}
+	if policy.BasePolicy != nil && policy.BasePolicy.TenantId != "" && !inTenant(policy.BasePolicy.TenantId) {
+		problems = append(problems, fmt.Errorf("the BasePolicy of the policy XML is in another tenant, %q, than the configured tenant (%s)", policy.BasePolicy.TenantId, tenant))
+	}
+	return problems
 }
 
 func policyXmlValidate(val interface{}, p cty.Path) diag.Diagnostics {
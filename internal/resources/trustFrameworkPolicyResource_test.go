@@ -4,11 +4,9 @@ import (
 	"context"
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/acceptance"
 	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/client"
-	"net/http"
 	"os"
 	"regexp"
 	"testing"
@@ -18,11 +16,7 @@ func TestAccCustomPolicy(t *testing.T) {
 	resourceName := "azureadb2cief_trust_framework_policy.TrustFrameworkBase"
 
 	resource.Test(t, resource.TestCase{
-		ProviderFactories: map[string]func() (*schema.Provider, error){
-			"azureadb2cief": func() (*schema.Provider, error) {
-				return acceptance.AzureADB2CProvider, nil
-			},
-		},
+		ProtoV5ProviderFactories: acceptance.ProtoV5ProviderFactories,
 		PreCheck: func() {
 			preCheckEnv(t)
 		},
@@ -43,11 +37,7 @@ func TestAccCustomPolicyE2E(t *testing.T) {
 	extResourceName := "azureadb2cief_trust_framework_policy.TrustFrameworkExtensions"
 
 	resource.Test(t, resource.TestCase{
-		ProviderFactories: map[string]func() (*schema.Provider, error){
-			"azureadb2cief": func() (*schema.Provider, error) {
-				return acceptance.AzureADB2CProvider, nil
-			},
-		},
+		ProtoV5ProviderFactories: acceptance.ProtoV5ProviderFactories,
 		ExternalProviders: map[string]resource.ExternalProvider{
 			"time": {
 				Source:            "hashicorp/time",
@@ -79,11 +69,7 @@ func TestAccCustomPolicyE2E(t *testing.T) {
 
 func TestAccCustomPolicyInvalidXml(t *testing.T) {
 	resource.Test(t, resource.TestCase{
-		ProviderFactories: map[string]func() (*schema.Provider, error){
-			"azureadb2cief": func() (*schema.Provider, error) {
-				return acceptance.AzureADB2CProvider, nil
-			},
-		},
+		ProtoV5ProviderFactories: acceptance.ProtoV5ProviderFactories,
 		PreCheck: func() {
 			preCheckEnv(t)
 		},
@@ -97,11 +83,7 @@ func TestAccCustomPolicyInvalidXml(t *testing.T) {
 }
 func TestAccCustomPolicyInvalidXml2(t *testing.T) {
 	resource.Test(t, resource.TestCase{
-		ProviderFactories: map[string]func() (*schema.Provider, error){
-			"azureadb2cief": func() (*schema.Provider, error) {
-				return acceptance.AzureADB2CProvider, nil
-			},
-		},
+		ProtoV5ProviderFactories: acceptance.ProtoV5ProviderFactories,
 		PreCheck: func() {
 			preCheckEnv(t)
 		},
@@ -131,16 +113,16 @@ func testPolicyExists(resourceName string) resource.TestCheckFunc {
 	return func(state *terraform.State) error {
 		policyClient := acceptance.AzureADB2CProvider.Meta().(*client.Client).TrustFrameworkPolicyClient
 		id := state.RootModule().Resources[resourceName].Primary.ID
-		r, status, err := policyClient.Get(context.Background(), id)
+		r, err := policyClient.Get(context.Background(), id)
 		if err != nil {
 			return err
 		}
 
-		if status == http.StatusOK && r != nil && r.Name == id {
+		if r != nil && r.Name == id {
 			return nil
 		}
 
-		return fmt.Errorf("trustframeworkpolicy policyClient returned invalid status (%v) when checking azure", status)
+		return fmt.Errorf("trustframeworkpolicy policyClient returned an unexpected policy when checking azure")
 	}
 }
 
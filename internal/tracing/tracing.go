@@ -0,0 +1,102 @@
+// Package tracing configures optional OpenTelemetry tracing of resource operations and Microsoft
+// Graph requests.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"os"
+	"strings"
+)
+
+const (
+	instrumentationName = "github.com/pjfebbraro/terraform-provider-azureadb2cief"
+	serviceName         = "terraform-provider-azureadb2cief"
+)
+
+// Attributes of the provider's spans.
+const (
+	ResourceType         = attribute.Key("azureadb2cief.resource.type")
+	PolicyName           = attribute.Key("azureadb2cief.policy.name")
+	KeySetName           = attribute.Key("azureadb2cief.key_set.name")
+	GraphRequestId       = attribute.Key("azureadb2cief.graph.request_id")
+	GraphClientRequestId = attribute.Key("azureadb2cief.graph.client_request_id")
+	RetryCount           = attribute.Key("azureadb2cief.graph.retry_count")
+)
+
+// Tracer returns the provider's tracer.  Spans are not recorded unless Configure enabled tracing.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Configure exports spans with OTLP when the standard OTEL_* environment variables ask for it:
+// either OTEL_TRACES_EXPORTER is otlp, or an OTLP endpoint is set and OTEL_TRACES_EXPORTER is not.
+// The endpoint, headers, protocol, sampler and resource are all read from the environment.
+// The returned function flushes and stops the exporter.
+func Configure(ctx context.Context, version string) (func(context.Context) error, error) {
+	noop := func(context.Context) error { return nil }
+
+	if !enabled() {
+		return noop, nil
+	}
+
+	exporter, err := newExporter(ctx)
+	if err != nil {
+		return noop, err
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(
+			attribute.String("service.name", serviceName),
+			attribute.String("service.version", version),
+		),
+		resource.WithTelemetrySDK(),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return noop, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+func enabled() bool {
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") {
+		return false
+	}
+	if exporter := strings.TrimSpace(os.Getenv("OTEL_TRACES_EXPORTER")); exporter != "" {
+		// The console exporter is not supported as stdout is reserved for the plugin protocol.
+		return strings.EqualFold(exporter, "otlp")
+	}
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+func newExporter(ctx context.Context) (*otlptrace.Exporter, error) {
+	protocol := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL")
+	if protocol == "" {
+		protocol = os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL")
+	}
+
+	switch strings.ToLower(protocol) {
+	case "", "http/protobuf":
+		return otlptracehttp.New(ctx)
+	case "grpc":
+		return otlptracegrpc.New(ctx)
+	default:
+		return nil, fmt.Errorf("unsupported OTLP protocol %q, expected grpc or http/protobuf", protocol)
+	}
+}
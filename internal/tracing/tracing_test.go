@@ -0,0 +1,66 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+)
+
+func TestEnabled(t *testing.T) {
+	cases := map[string]struct {
+		env      map[string]string
+		expected bool
+	}{
+		"not configured": {
+			env:      map[string]string{},
+			expected: false,
+		},
+		"otlp exporter": {
+			env:      map[string]string{"OTEL_TRACES_EXPORTER": "otlp"},
+			expected: true,
+		},
+		"endpoint": {
+			env:      map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4318"},
+			expected: true,
+		},
+		"traces endpoint": {
+			env:      map[string]string{"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT": "http://localhost:4318/v1/traces"},
+			expected: true,
+		},
+		"no exporter": {
+			env:      map[string]string{"OTEL_TRACES_EXPORTER": "none", "OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4318"},
+			expected: false,
+		},
+		"console exporter": {
+			env:      map[string]string{"OTEL_TRACES_EXPORTER": "console"},
+			expected: false,
+		},
+		"sdk disabled": {
+			env:      map[string]string{"OTEL_SDK_DISABLED": "true", "OTEL_TRACES_EXPORTER": "otlp"},
+			expected: false,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			for _, variable := range []string{"OTEL_SDK_DISABLED", "OTEL_TRACES_EXPORTER", "OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"} {
+				t.Setenv(variable, c.env[variable])
+			}
+			if actual := enabled(); actual != c.expected {
+				t.Fatalf("expected %t, got %t", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestConfigureUnsupportedProtocol(t *testing.T) {
+	t.Setenv("OTEL_TRACES_EXPORTER", "otlp")
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "http/json")
+
+	shutdown, err := Configure(context.Background(), "test")
+	if err == nil {
+		t.Fatal("expected an unsupported protocol to fail")
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,187 @@
+package util
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// TrustFrameworkPolicy holds the identifying attributes of a policy's root TrustFrameworkPolicy element.
+type TrustFrameworkPolicy struct {
+	PolicyId        string
+	TenantId        string
+	PublicPolicyUri string
+	BasePolicy      *BasePolicy
+}
+
+// BasePolicy is the policy a policy inherits from.
+type BasePolicy struct {
+	TenantId string
+	PolicyId string
+}
+
+// ParseTrustFrameworkPolicy reads the root element and the BasePolicy element of a policy.
+func ParseTrustFrameworkPolicy(policyXml string) (*TrustFrameworkPolicy, error) {
+	var root struct {
+		XMLName         xml.Name `xml:"TrustFrameworkPolicy"`
+		PolicyId        string   `xml:"PolicyId,attr"`
+		TenantId        string   `xml:"TenantId,attr"`
+		PublicPolicyUri string   `xml:"PublicPolicyUri,attr"`
+		BasePolicy      *struct {
+			TenantId string `xml:"TenantId"`
+			PolicyId string `xml:"PolicyId"`
+		} `xml:"BasePolicy"`
+	}
+	if err := xml.Unmarshal([]byte(policyXml), &root); err != nil {
+		return nil, fmt.Errorf("could not parse the TrustFrameworkPolicy element: %s", err)
+	}
+	if strings.TrimSpace(root.PolicyId) == "" {
+		return nil, fmt.Errorf("the TrustFrameworkPolicy element has no PolicyId attribute")
+	}
+
+	policy := &TrustFrameworkPolicy{
+		PolicyId:        strings.TrimSpace(root.PolicyId),
+		TenantId:        strings.TrimSpace(root.TenantId),
+		PublicPolicyUri: strings.TrimSpace(root.PublicPolicyUri),
+	}
+	if root.BasePolicy != nil {
+		policy.BasePolicy = &BasePolicy{
+			TenantId: strings.TrimSpace(root.BasePolicy.TenantId),
+			PolicyId: strings.TrimSpace(root.BasePolicy.PolicyId),
+		}
+	}
+	return policy, nil
+}
+
+// ReferencedKeySets returns the key sets a policy refers to with StorageReferenceId attributes, sorted
+// and without duplicates.
+func ReferencedKeySets(policyXml string) ([]string, error) {
+	decoder := xml.NewDecoder(strings.NewReader(policyXml))
+	seen := map[string]bool{}
+	keySets := []string{}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not parse the policy: %s", err)
+		}
+
+		element, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		for _, attr := range element.Attr {
+			id := strings.TrimSpace(attr.Value)
+			if attr.Name.Local == "StorageReferenceId" && id != "" && !seen[id] {
+				seen[id] = true
+				keySets = append(keySets, id)
+			}
+		}
+	}
+
+	sort.Strings(keySets)
+	return keySets, nil
+}
+
+// RenamePolicyIds renames the policies a policy names with rename: the PolicyId and the last segment
+// of the PublicPolicyUri of its root element, and the PolicyId of its BasePolicy.  When keySets is
+// true, the key sets of its StorageReferenceId attributes are renamed as well.  Only the renamed
+// values change, the rest of the XML is kept as it is.
+func RenamePolicyIds(policyXml string, rename func(string) string, keySets bool) (string, error) {
+	type edit struct {
+		start, end int
+		value      string
+	}
+	var edits []edit
+	// replace renames the value between start and end, keeping the whitespace around it.
+	replace := func(start int, end int, f func(string) string) {
+		raw := policyXml[start:end]
+		value := strings.TrimSpace(raw)
+		if value == "" {
+			return
+		}
+		if renamed := f(value); renamed != value {
+			offset := start + strings.Index(raw, value)
+			edits = append(edits, edit{offset, offset + len(value), renamed})
+		}
+	}
+	renameUri := func(uri string) string {
+		i := strings.LastIndex(uri, "/")
+		return uri[:i+1] + rename(uri[i+1:])
+	}
+
+	decoder := xml.NewDecoder(strings.NewReader(policyXml))
+	var path []string
+	for {
+		start := int(decoder.InputOffset())
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("could not parse the policy: %s", err)
+		}
+		end := int(decoder.InputOffset())
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			path = append(path, t.Name.Local)
+			for _, attr := range attributeValues(policyXml[start:end]) {
+				switch {
+				case len(path) == 1 && attr.name == "PolicyId":
+					replace(start+attr.start, start+attr.end, rename)
+				case len(path) == 1 && attr.name == "PublicPolicyUri":
+					replace(start+attr.start, start+attr.end, renameUri)
+				case keySets && attr.name == "StorageReferenceId":
+					replace(start+attr.start, start+attr.end, rename)
+				}
+			}
+		case xml.EndElement:
+			path = path[:len(path)-1]
+		case xml.CharData:
+			if len(path) == 3 && path[1] == "BasePolicy" && path[2] == "PolicyId" {
+				replace(start, end, rename)
+			}
+		}
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, e := range edits {
+		b.WriteString(policyXml[last:e.start])
+		b.WriteString(e.value)
+		last = e.end
+	}
+	b.WriteString(policyXml[last:])
+	return b.String(), nil
+}
+
+// attributeValue is the position of the value of an attribute in the text of a start tag.
+type attributeValue struct {
+	name       string
+	start, end int
+}
+
+var attributePattern = regexp.MustCompile(`([\w.:-]+)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+
+// attributeValues finds the values of the attributes of a start tag, named without their namespace prefix.
+func attributeValues(tag string) []attributeValue {
+	var values []attributeValue
+	for _, m := range attributePattern.FindAllStringSubmatchIndex(tag, -1) {
+		name := tag[m[2]:m[3]]
+		if i := strings.LastIndex(name, ":"); i >= 0 {
+			name = name[i+1:]
+		}
+		start, end := m[4], m[5]
+		if start < 0 {
+			start, end = m[6], m[7]
+		}
+		values = append(values, attributeValue{name, start, end})
+	}
+	return values
+}
@@ -0,0 +1,139 @@
+package util_test
+
+import (
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/util"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTrustFrameworkPolicy(t *testing.T) {
+	xmlBytes, err := os.ReadFile("./testdata/TestXml.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	policy, err := util.ParseTrustFrameworkPolicy(string(xmlBytes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.PolicyId != "B2C_1A_TrustFrameworkBase" {
+		t.Errorf("unexpected PolicyId %s", policy.PolicyId)
+	}
+	if policy.TenantId != "${tenant_name}.onmicrosoft.com" {
+		t.Errorf("unexpected TenantId %s", policy.TenantId)
+	}
+	if policy.PublicPolicyUri != "http://${tenant_name}.onmicrosoft.com/B2C_1A_TrustFrameworkBase" {
+		t.Errorf("unexpected PublicPolicyUri %s", policy.PublicPolicyUri)
+	}
+	if policy.BasePolicy != nil {
+		t.Errorf("expected no BasePolicy, got %+v", policy.BasePolicy)
+	}
+
+	child, err := util.ParseTrustFrameworkPolicy(`<TrustFrameworkPolicy xmlns="http://schemas.microsoft.com/online/cpim/schemas/2013/06" PolicyId="B2C_1A_Child">
+  <BasePolicy>
+    <TenantId>contoso.onmicrosoft.com</TenantId>
+    <PolicyId>B2C_1A_TrustFrameworkBase</PolicyId>
+  </BasePolicy>
+</TrustFrameworkPolicy>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if child.BasePolicy == nil || child.BasePolicy.PolicyId != "B2C_1A_TrustFrameworkBase" || child.BasePolicy.TenantId != "contoso.onmicrosoft.com" {
+		t.Errorf("unexpected BasePolicy %+v", child.BasePolicy)
+	}
+
+	if _, err := util.ParseTrustFrameworkPolicy(`<Other PolicyId="B2C_1A_Test" />`); err == nil {
+		t.Error("expected an error for a document without a TrustFrameworkPolicy root")
+	}
+}
+
+func TestReferencedKeySets(t *testing.T) {
+	xmlBytes, err := os.ReadFile("./testdata/TestXml.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keySets, err := util.ReferencedKeySets(string(xmlBytes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The B2C_1A_FacebookSecret key in the policy is commented out.
+	expected := []string{"${token_encryption_key_container}", "${token_signing_key_container}"}
+	if !reflect.DeepEqual(keySets, expected) {
+		t.Fatalf("expected %v, got %v", expected, keySets)
+	}
+
+	keySets, err = util.ReferencedKeySets(`<TrustFrameworkPolicy PolicyId="B2C_1A_Test" />`)
+	if err != nil || keySets == nil || len(keySets) != 0 {
+		t.Fatalf("expected an empty list for a policy without keys, got %v (%v)", keySets, err)
+	}
+
+	if _, err := util.ReferencedKeySets(`<TrustFrameworkPolicy>`); err == nil {
+		t.Fatal("expected an error for a policy that is not well formed")
+	}
+}
+
+func TestRenamePolicyIds(t *testing.T) {
+	addPrefix := func(name string) string {
+		return strings.Replace(name, "B2C_1A_", "B2C_1A_alice_", 1)
+	}
+	removePrefix := func(name string) string {
+		return strings.Replace(name, "B2C_1A_alice_", "B2C_1A_", 1)
+	}
+
+	policyXml := `<?xml version="1.0" encoding="utf-8" ?>
+<TrustFrameworkPolicy xmlns="http://schemas.microsoft.com/online/cpim/schemas/2013/06" TenantId="contoso.onmicrosoft.com"
+  PolicyId = 'B2C_1A_SignUpOrSignin' PublicPolicyUri="http://contoso.onmicrosoft.com/B2C_1A_SignUpOrSignin">
+  <BasePolicy>
+    <TenantId>contoso.onmicrosoft.com</TenantId>
+    <PolicyId> B2C_1A_TrustFrameworkExtensions </PolicyId>
+  </BasePolicy>
+  <ClaimsProviders>
+    <ClaimsProvider>
+      <TechnicalProfile Id="Facebook-OAUTH">
+        <Metadata>
+          <Item Key="PolicyId">B2C_1A_Unchanged</Item>
+        </Metadata>
+        <CryptographicKeys>
+          <Key Id="client_secret" StorageReferenceId="B2C_1A_FacebookSecret" />
+        </CryptographicKeys>
+      </TechnicalProfile>
+    </ClaimsProvider>
+  </ClaimsProviders>
+</TrustFrameworkPolicy>`
+
+	renamed, err := util.RenamePolicyIds(policyXml, addPrefix, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.NewReplacer(
+		"'B2C_1A_SignUpOrSignin'", "'B2C_1A_alice_SignUpOrSignin'",
+		"/B2C_1A_SignUpOrSignin", "/B2C_1A_alice_SignUpOrSignin",
+		" B2C_1A_TrustFrameworkExtensions ", " B2C_1A_alice_TrustFrameworkExtensions ",
+	).Replace(policyXml)
+	if renamed != expected {
+		t.Fatalf("unexpected renamed policy:\n%s", renamed)
+	}
+
+	renamedKeySets, err := util.RenamePolicyIds(policyXml, addPrefix, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamedKeySets != strings.Replace(expected, `"B2C_1A_FacebookSecret"`, `"B2C_1A_alice_FacebookSecret"`, 1) {
+		t.Fatalf("expected the key sets to be renamed:\n%s", renamedKeySets)
+	}
+
+	restored, err := util.RenamePolicyIds(renamedKeySets, removePrefix, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored != policyXml {
+		t.Fatalf("expected renaming back to restore the policy, got:\n%s", restored)
+	}
+
+	if _, err := util.RenamePolicyIds("<TrustFrameworkPolicy", addPrefix, false); err == nil {
+		t.Error("expected an error for a policy that is not valid XML")
+	}
+}
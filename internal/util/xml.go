@@ -0,0 +1,75 @@
+package util
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// EqualXml reports whether two XML documents have the same canonical form.  Documents that are not
+// well formed are never equal.
+func EqualXml(a, b string) bool {
+	canonicalA, err := CanonicalXml(a)
+
+	if err != nil {
+		return false
+	}
+
+	canonicalB, err := CanonicalXml(b)
+
+	if err != nil {
+		return false
+	}
+
+	return canonicalA == canonicalB
+}
+
+// CanonicalXml returns the form of an XML document that EqualXml compares.  The XML declaration,
+// comments and whitespace between elements are removed and the elements are indented.
+func CanonicalXml(s string) (string, error) {
+	reader := strings.NewReader(s)
+	decoder := xml.NewDecoder(reader)
+	var tokens []xml.Token
+	var err error
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if err == io.EOF {
+				break
+			} else {
+				return "", err
+			}
+		}
+
+		if _, ok := token.(xml.ProcInst); ok {
+			continue
+		}
+		if charData, ok := token.(xml.CharData); ok {
+			if len(strings.TrimSpace(string(charData))) == 0 {
+				continue
+			}
+		}
+		if _, ok := token.(xml.Comment); ok {
+			continue
+		}
+		tokens = append(tokens, xml.CopyToken(token))
+	}
+
+	var outBuffer bytes.Buffer
+	encoder := xml.NewEncoder(&outBuffer)
+	encoder.Indent("", " ")
+	for _, v := range tokens {
+		err = encoder.EncodeToken(v)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err = encoder.Flush()
+	if err != nil {
+		return "", err
+	}
+
+	return outBuffer.String(), nil
+}
@@ -0,0 +1,51 @@
+package util_test
+
+import (
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/util"
+	"os"
+	"testing"
+)
+
+func TestCanonicalXml(t *testing.T) {
+	xmlbytes1, err := os.ReadFile("./testdata/TestXml.xml")
+	if err != nil {
+		t.Error(err)
+	}
+	xml := string(xmlbytes1)
+
+	xmlBytes2, err := os.ReadFile("./testdata/TestXml2.xml")
+	if err != nil {
+		t.Error(err)
+	}
+	xml2 := string(xmlBytes2)
+
+	isDifferent := util.EqualXml(xml, xml2)
+	if isDifferent == false {
+		t.Fatalf("Diff returned false")
+	}
+}
+
+func TestCanonicalXmlMatchesEqualXml(t *testing.T) {
+	a := `<?xml version="1.0"?>
+<TrustFrameworkPolicy PolicyId="B2C_1A_Test">
+  <!-- comment -->
+  <BuildingBlocks />
+</TrustFrameworkPolicy>`
+	b := `<TrustFrameworkPolicy PolicyId="B2C_1A_Test"><BuildingBlocks></BuildingBlocks></TrustFrameworkPolicy>`
+
+	canonicalA, err := util.CanonicalXml(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	canonicalB, err := util.CanonicalXml(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if canonicalA != canonicalB || !util.EqualXml(a, b) {
+		t.Fatalf("expected the same canonical form, got %q and %q", canonicalA, canonicalB)
+	}
+
+	if _, err := util.CanonicalXml("<TrustFrameworkPolicy>"); err == nil {
+		t.Fatal("expected an error for a policy that is not well formed")
+	}
+}
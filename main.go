@@ -5,8 +5,9 @@ import (
 	"flag"
 	"log"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/plugin"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5/tf5server"
 	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/provider"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/tracing"
 )
 
 // Run "go generate" to format example terraform files and generate the docs for the registry/website
@@ -34,15 +35,28 @@ func main() {
 	flag.BoolVar(&debugMode, "debug", false, "set to true to run the provider with support for debuggers like delve")
 	flag.Parse()
 
-	opts := &plugin.ServeOpts{ProviderFunc: provider.New(version)}
+	shutdownTracing, err := tracing.Configure(context.Background(), version)
+	if err != nil {
+		log.Printf("[WARN] OpenTelemetry tracing is disabled: %s", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.Printf("[WARN] Could not flush OpenTelemetry spans: %s", err)
+		}
+	}()
+
+	muxServer, err := provider.NewMuxServer(context.Background(), version, provider.New(version)())
+	if err != nil {
+		log.Fatal(err.Error())
+	}
 
+	var serveOpts []tf5server.ServeOpt
 	if debugMode {
-		err := plugin.Debug(context.Background(), "registry.terraform.io/pjfebbraro/azureadb2cief", opts)
-		if err != nil {
-			log.Fatal(err.Error())
-		}
-		return
+		serveOpts = append(serveOpts, tf5server.WithManagedDebug())
 	}
 
-	plugin.Serve(opts)
+	err = tf5server.Serve("registry.terraform.io/pjfebbraro/azureadb2cief", muxServer, serveOpts...)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
 }
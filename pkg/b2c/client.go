@@ -0,0 +1,38 @@
+package b2c
+
+import (
+	"context"
+)
+
+// PolicyClient reads and manages the Trust Framework policies of a tenant.
+type PolicyClient interface {
+	// Get returns the policy with the given name.
+	Get(ctx context.Context, name string) (*Policy, error)
+	// List returns a pager over the policies of the tenant.  Only their names are returned, and
+	// Get reads their XML.
+	List(options ...ListOption) *Pager[Policy]
+	// Create uploads a new policy, named by the PolicyId of its XML.
+	Create(ctx context.Context, policyXml *string) error
+	// Update replaces the XML of an existing policy.
+	Update(ctx context.Context, policy *Policy) error
+	// Delete deletes the policy with the given name.
+	Delete(ctx context.Context, name string) error
+}
+
+// KeySetClient reads and manages the Trust Framework key sets of a tenant.
+type KeySetClient interface {
+	// GetKeySet returns the key set with the given id and the public parts of its keys.
+	GetKeySet(ctx context.Context, id string) (*TrustFrameworkKeySet, error)
+	// GetActiveKey returns the key of a key set that is currently used.
+	GetActiveKey(ctx context.Context, id string) (*TrustFrameworkKey, error)
+	// ListKeySets returns a pager over the key sets of the tenant.
+	ListKeySets(options ...ListOption) *Pager[TrustFrameworkKeySet]
+	// CreateKey creates an empty key set.
+	CreateKey(ctx context.Context, id string) (*TrustFrameworkKeySet, error)
+	// GenerateKey adds a key generated by Microsoft Graph with the Use, Kty, Nbf and Exp of key.
+	GenerateKey(ctx context.Context, id string, key TrustFrameworkKey) error
+	// UploadSecret adds a symmetric key with the value K of key.
+	UploadSecret(ctx context.Context, id string, key TrustFrameworkKey) error
+	// DeleteKey deletes the key set with the given id.
+	DeleteKey(ctx context.Context, id string) error
+}
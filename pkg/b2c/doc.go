@@ -0,0 +1,9 @@
+// Package b2c is a client for the custom policies and policy keys of an Azure AD B2C tenant, the
+// trustFramework resources of the Microsoft Graph beta API.
+//
+// The PolicyClient and KeySetClient interfaces are what the azureadb2cief Terraform provider uses
+// to manage policies and key sets.  Package msgraph implements them against Microsoft Graph, with
+// the provider's retries, throttling, request batching, logging and tracing, so that other tools can
+// read and manage a tenant the same way the provider does.  Code that depends on the interfaces can
+// be tested with fakes instead of a tenant.
+package b2c
@@ -0,0 +1,76 @@
+package b2c
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrReadOnly is returned for a request that could change the tenant when the client is read-only.
+var ErrReadOnly = errors.New("the client is read-only and only sends GET requests")
+
+// GraphError is an error response from Microsoft Graph.
+type GraphError struct {
+	StatusCode      int
+	Code            string
+	Message         string
+	RequestId       string
+	ClientRequestId string
+	Date            string
+	// Body is the raw response body, kept when it is not a Graph error envelope.
+	Body string
+}
+
+func (e *GraphError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "unexpected status %d", e.StatusCode)
+	if e.Code != "" {
+		fmt.Fprintf(&b, " (%s)", e.Code)
+	}
+	if e.Message != "" {
+		fmt.Fprintf(&b, ": %s", e.Message)
+	} else if e.Body != "" {
+		fmt.Fprintf(&b, " with response: %s", e.Body)
+	} else {
+		b.WriteString(" received with no body")
+	}
+	if e.RequestId != "" {
+		fmt.Fprintf(&b, " (request-id: %s)", e.RequestId)
+	}
+	return b.String()
+}
+
+// AsGraphError returns the GraphError in err's chain, if there is one.
+func AsGraphError(err error) (*GraphError, bool) {
+	var graphErr *GraphError
+	if errors.As(err, &graphErr) {
+		return graphErr, true
+	}
+	return nil, false
+}
+
+func hasStatus(err error, status int) bool {
+	graphErr, ok := AsGraphError(err)
+	return ok && graphErr.StatusCode == status
+}
+
+// IsNotFound reports whether err is a 404 Not Found response from Microsoft Graph.
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsConflict reports whether err is a 409 Conflict response from Microsoft Graph.
+func IsConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict)
+}
+
+// IsForbidden reports whether err is a 403 Forbidden response from Microsoft Graph.
+func IsForbidden(err error) bool {
+	return hasStatus(err, http.StatusForbidden)
+}
+
+// IsThrottled reports whether err is a 429 Too Many Requests response from Microsoft Graph.
+func IsThrottled(err error) bool {
+	return hasStatus(err, http.StatusTooManyRequests)
+}
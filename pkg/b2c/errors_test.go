@@ -0,0 +1,37 @@
+package b2c
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestGraphErrorHelpers(t *testing.T) {
+	cases := []struct {
+		status int
+		check  func(error) bool
+	}{
+		{http.StatusNotFound, IsNotFound},
+		{http.StatusConflict, IsConflict},
+		{http.StatusForbidden, IsForbidden},
+		{http.StatusTooManyRequests, IsThrottled},
+	}
+
+	for _, c := range cases {
+		err := &GraphError{StatusCode: c.status}
+		if !c.check(err) {
+			t.Errorf("expected status %d to match", c.status)
+		}
+		if !c.check(fmt.Errorf("wrapped: %w", err)) {
+			t.Errorf("expected a wrapped status %d to match", c.status)
+		}
+		if c.check(&GraphError{StatusCode: http.StatusInternalServerError}) {
+			t.Errorf("expected status 500 not to match the helper for %d", c.status)
+		}
+	}
+
+	if IsNotFound(errors.New("not found")) || IsNotFound(nil) {
+		t.Error("expected errors that are not from Microsoft Graph not to match")
+	}
+}
@@ -0,0 +1,62 @@
+package b2c
+
+// Policy is a Trust Framework policy, a custom policy of an Azure AD B2C tenant.
+type Policy struct {
+	// Name is the id of the policy, the PolicyId of its XML, for example B2C_1A_signup_signin.
+	Name string
+	// Policy is the XML of the policy.  Policies returned by List only have a Name.
+	Policy string
+}
+
+// KeyStatus is the status of a key in a key set.
+type KeyStatus string
+
+const (
+	KeyStatusEnabled  KeyStatus = "enabled"
+	KeyStatusDisabled KeyStatus = "disabled"
+)
+
+// TrustFrameworkKey is a key of a key set, a JSON Web Key as defined in RFC 7517.  Microsoft Graph
+// never returns private key material, so D, Dp, Dq, P, Q, Qi and K are only set in requests.
+type TrustFrameworkKey struct {
+	// Kid is the key id.
+	Kid *string `json:"kid,omitempty"`
+	// Use is the intended use of the key, sig for signing or enc for encryption.
+	Use *string `json:"use,omitempty"`
+	// Kty is the key type, RSA or oct.
+	Kty *string `json:"kty,omitempty"`
+	// Nbf is the time before which the key is not valid, a NumericDate as defined in RFC 7519.
+	Nbf *int `json:"nbf,omitempty"`
+	// Exp is the time the key expires, a NumericDate as defined in RFC 7519.
+	Exp *int `json:"exp,omitempty"`
+	// Status is whether the key is enabled.
+	Status *KeyStatus `json:"status,omitempty"`
+	// E is the exponent of an RSA public key.
+	E *string `json:"e,omitempty"`
+	// N is the modulus of an RSA public key.
+	N *string `json:"n,omitempty"`
+	// X5c is the certificate chain of the key.
+	X5c []string `json:"x5c,omitempty"`
+	// X5t is the SHA-1 thumbprint of the key's certificate.
+	X5t *string `json:"x5t,omitempty"`
+	// D is the private exponent of an RSA key.
+	D *string `json:"d,omitempty"`
+	// Dp is the first factor CRT exponent of an RSA key.
+	Dp *string `json:"dp,omitempty"`
+	// Dq is the second factor CRT exponent of an RSA key.
+	Dq *string `json:"dq,omitempty"`
+	// P is the first prime factor of an RSA key.
+	P *string `json:"p,omitempty"`
+	// Q is the second prime factor of an RSA key.
+	Q *string `json:"q,omitempty"`
+	// Qi is the first CRT coefficient of an RSA key.
+	Qi *string `json:"qi,omitempty"`
+	// K is the value of a symmetric key.
+	K *string `json:"k,omitempty"`
+}
+
+// TrustFrameworkKeySet is a key set, the policy key custom policies refer to by StorageReferenceId.
+type TrustFrameworkKeySet struct {
+	Id   *string             `json:"id"`
+	Keys []TrustFrameworkKey `json:"keys"`
+}
@@ -0,0 +1,97 @@
+// Package msgraph implements the b2c clients with Microsoft Graph.
+package msgraph
+
+import (
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/internal/graph"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/pkg/b2c"
+	"strings"
+)
+
+const (
+	// DefaultMaxRetries is the number of times a failed or throttled request is retried by default.
+	DefaultMaxRetries = 3
+
+	// DefaultMaxRequestsPerSecond is the default limit of requests per second sent to a tenant.
+	DefaultMaxRequestsPerSecond = 10
+
+	// DefaultUserAgent is the default User-Agent of requests.
+	DefaultUserAgent = "azureadb2cief-b2c-go"
+)
+
+// Options are the options of a Client.  The zero value of each option is its default.
+type Options struct {
+	// Environment is the national cloud of the tenant: global, usgovernment or china.
+	Environment string
+	// GraphEndpoint replaces the Microsoft Graph endpoint of the environment, for example with a test server.
+	GraphEndpoint string
+	// MaxRetries is the maximum number of times a failed or throttled request is retried.  A
+	// negative value disables retries.
+	MaxRetries int
+	// MaxRequestsPerSecond limits the requests sent to the tenant by every Client of the process.  A
+	// negative value disables the limit.
+	MaxRequestsPerSecond float64
+	// DisableBatching sends every read on its own instead of batching concurrent reads.
+	DisableBatching bool
+	// UserAgent identifies the application in the User-Agent of requests.
+	UserAgent string
+	// ReadOnly refuses every request that could change the tenant with b2c.ErrReadOnly.
+	ReadOnly bool
+	// PolicyNamePrefix replaces the B2C_1A_ prefix of the names of policies in the tenant, and of the
+	// policies their XML refers to.  Names are given and returned with the B2C_1A_ prefix, and only
+	// the policies with the prefix are listed.
+	PolicyNamePrefix string
+	// PrefixKeySets applies PolicyNamePrefix to key sets and StorageReferenceId attributes as well.
+	PrefixKeySets bool
+}
+
+// Client reads and manages the policies and key sets of a tenant with Microsoft Graph.
+type Client struct {
+	Policies b2c.PolicyClient
+	KeySets  b2c.KeySetClient
+}
+
+// New returns a Client for the tenant which authenticates with cred.  options may be nil.
+func New(tenantID string, cred azcore.TokenCredential, options *Options) (*Client, error) {
+	if options == nil {
+		options = &Options{}
+	}
+
+	config := graph.Config{
+		TenantID:             tenantID,
+		Credential:           cred,
+		Environment:          options.Environment,
+		GraphEndpoint:        options.GraphEndpoint,
+		MaxRetries:           orDefault(options.MaxRetries, DefaultMaxRetries),
+		MaxRequestsPerSecond: orDefault(options.MaxRequestsPerSecond, DefaultMaxRequestsPerSecond),
+		BatchRequests:        !options.DisableBatching,
+		UserAgent:            options.UserAgent,
+		ReadOnly:             options.ReadOnly,
+		PolicyNamePrefix:     options.PolicyNamePrefix,
+		PrefixKeySets:        options.PrefixKeySets,
+	}
+	if strings.TrimSpace(config.UserAgent) == "" {
+		config.UserAgent = DefaultUserAgent
+	}
+
+	c, err := graph.New(config)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		Policies: c.Policies,
+		KeySets:  c.KeySets,
+	}, nil
+}
+
+// orDefault returns the default for a zero value and zero for a negative one.
+func orDefault[T int | float64](value T, defaultValue T) T {
+	var zero T
+	switch {
+	case value == zero:
+		return defaultValue
+	case value < zero:
+		return zero
+	}
+	return value
+}
@@ -0,0 +1,61 @@
+package msgraph
+
+import (
+	"context"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/pjfebbraro/terraform-provider-azureadb2cief/pkg/b2c"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type staticCredential string
+
+func (s staticCredential) GetToken(_ context.Context, _ policy.TokenRequestOptions) (*azcore.AccessToken, error) {
+	return &azcore.AccessToken{Token: string(s), ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+func TestNew(t *testing.T) {
+	var authorization, userAgent string
+	graph := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization, userAgent = r.Header.Get("Authorization"), r.Header.Get("User-Agent")
+		if r.URL.Path != "/beta/trustframework/policies/B2C_1A_Test/$value" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte(`<TrustFrameworkPolicy PolicyId="B2C_1A_Test" />`))
+	}))
+	t.Cleanup(graph.Close)
+
+	c, err := New("contoso.onmicrosoft.com", staticCredential("token"), &Options{GraphEndpoint: graph.URL, MaxRetries: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	policy, err := c.Policies.Get(context.Background(), "B2C_1A_Test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.Policy != `<TrustFrameworkPolicy PolicyId="B2C_1A_Test" />` {
+		t.Fatalf("unexpected policy %q", policy.Policy)
+	}
+	if authorization != "Bearer token" || userAgent != DefaultUserAgent {
+		t.Fatalf("expected the credential's token and the default User-Agent, got %q and %q", authorization, userAgent)
+	}
+
+	if _, err := c.KeySets.GetKeySet(context.Background(), "B2C_1A_Missing"); !b2c.IsNotFound(err) {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+}
+
+func TestOrDefault(t *testing.T) {
+	if orDefault(0, DefaultMaxRetries) != DefaultMaxRetries || orDefault(-1, DefaultMaxRetries) != 0 || orDefault(5, DefaultMaxRetries) != 5 {
+		t.Fatal("unexpected retries")
+	}
+	if orDefault(0, DefaultMaxRequestsPerSecond) != DefaultMaxRequestsPerSecond || orDefault(-1.0, DefaultMaxRequestsPerSecond) != 0 {
+		t.Fatal("unexpected rate limit")
+	}
+}
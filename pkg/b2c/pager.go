@@ -0,0 +1,104 @@
+package b2c
+
+import (
+	"context"
+	"errors"
+	"iter"
+)
+
+// ErrNoMorePages is returned by NextPage after the last page.
+var ErrNoMorePages = errors.New("no more pages")
+
+// ListOptions are the options of a List request.
+type ListOptions struct {
+	// PageSize is the number of items Microsoft Graph is asked to return per page, or zero for
+	// its default.
+	PageSize int
+}
+
+// ListOption sets an option of a List request.
+type ListOption func(*ListOptions)
+
+// WithPageSize asks Microsoft Graph for pages of at most size items.
+func WithPageSize(size int) ListOption {
+	return func(o *ListOptions) {
+		o.PageSize = size
+	}
+}
+
+// NewListOptions applies options to the default options, for implementations of the clients.
+func NewListOptions(options ...ListOption) ListOptions {
+	var result ListOptions
+	for _, option := range options {
+		option(&result)
+	}
+	return result
+}
+
+// PageFunc fetches a page of a list.  nextLink is empty for the first page and otherwise the link
+// returned with the previous page.  It returns an empty link with the last page.
+type PageFunc[T any] func(ctx context.Context, nextLink string) (items []T, next string, err error)
+
+// Pager iterates over the pages of a list, fetching each page when it is needed.  A Pager is not
+// safe for concurrent use.
+type Pager[T any] struct {
+	fetch    PageFunc[T]
+	nextLink string
+	done     bool
+}
+
+// NewPager returns a Pager which fetches pages with fetch.
+func NewPager[T any](fetch PageFunc[T]) *Pager[T] {
+	return &Pager[T]{fetch: fetch}
+}
+
+// More reports whether there are more pages to fetch.
+func (p *Pager[T]) More() bool {
+	return !p.done
+}
+
+// NextPage fetches the next page.  A page that fails can be fetched again by calling NextPage again.
+func (p *Pager[T]) NextPage(ctx context.Context) ([]T, error) {
+	if p.done {
+		return nil, ErrNoMorePages
+	}
+
+	items, next, err := p.fetch(ctx, p.nextLink)
+	if err != nil {
+		return nil, err
+	}
+	p.nextLink = next
+	p.done = next == ""
+	return items, nil
+}
+
+// All iterates over the items of the remaining pages.  Iteration stops after yielding an error.
+func (p *Pager[T]) All(ctx context.Context) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for p.More() {
+			items, err := p.NextPage(ctx)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Collect returns the items of the remaining pages.
+func (p *Pager[T]) Collect(ctx context.Context) ([]T, error) {
+	var result []T
+	for item, err := range p.All(ctx) {
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
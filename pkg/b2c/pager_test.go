@@ -0,0 +1,115 @@
+package b2c
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// pages returns a PageFunc serving pages linked by their index.
+func pages(pages ...[]string) PageFunc[string] {
+	return func(ctx context.Context, nextLink string) ([]string, string, error) {
+		index := 0
+		if nextLink != "" {
+			index = int(nextLink[0] - '0')
+		}
+		next := ""
+		if index+1 < len(pages) {
+			next = string(rune('0' + index + 1))
+		}
+		return pages[index], next, nil
+	}
+}
+
+func TestPagerNextPage(t *testing.T) {
+	pager := NewPager(pages([]string{"a", "b"}, []string{"c"}))
+
+	var result [][]string
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		result = append(result, page)
+	}
+	if !reflect.DeepEqual(result, [][]string{{"a", "b"}, {"c"}}) {
+		t.Fatalf("unexpected pages %v", result)
+	}
+	if _, err := pager.NextPage(context.Background()); !errors.Is(err, ErrNoMorePages) {
+		t.Fatalf("expected ErrNoMorePages after the last page, got %v", err)
+	}
+}
+
+func TestPagerCollect(t *testing.T) {
+	items, err := NewPager(pages([]string{"a", "b"}, nil, []string{"c"})).Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(items, []string{"a", "b", "c"}) {
+		t.Fatalf("unexpected items %v", items)
+	}
+}
+
+func TestPagerAllStopsEarly(t *testing.T) {
+	fetched := 0
+	pager := NewPager(func(ctx context.Context, nextLink string) ([]string, string, error) {
+		fetched++
+		return []string{"a", "b"}, "next", nil
+	})
+
+	for item := range pager.All(context.Background()) {
+		if item == "b" {
+			break
+		}
+	}
+	if fetched != 1 {
+		t.Fatalf("expected a single page to be fetched, got %d", fetched)
+	}
+}
+
+func TestPagerRetriesFailedPage(t *testing.T) {
+	failure := errors.New("throttled")
+	var links []string
+	pager := NewPager(func(ctx context.Context, nextLink string) ([]string, string, error) {
+		links = append(links, nextLink)
+		if len(links) == 2 {
+			return nil, "", failure
+		}
+		if nextLink == "" {
+			return []string{"a"}, "second", nil
+		}
+		return []string{"b"}, "", nil
+	})
+
+	var items []string
+	var errs []error
+	for item, err := range pager.All(context.Background()) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		items = append(items, item)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], failure) || !pager.More() {
+		t.Fatalf("expected iteration to stop at the failed page, got %v", errs)
+	}
+
+	rest, err := pager.Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	items = append(items, rest...)
+	if !reflect.DeepEqual(items, []string{"a", "b"}) || !reflect.DeepEqual(links, []string{"", "second", "second"}) {
+		t.Fatalf("expected the failed page to be fetched again, got items %v and links %v", items, links)
+	}
+}
+
+func TestNewListOptions(t *testing.T) {
+	if options := NewListOptions(); options.PageSize != 0 {
+		t.Fatalf("expected the default page size, got %d", options.PageSize)
+	}
+	if options := NewListOptions(WithPageSize(5), WithPageSize(10)); options.PageSize != 10 {
+		t.Fatalf("expected the last page size, got %d", options.PageSize)
+	}
+}